@@ -2,13 +2,15 @@ module github.com/go-i2p/i2p-vanitygen
 
 go 1.24.0
 
-require gioui.org v0.8.0
+require (
+	filippo.io/edwards25519 v1.2.0
+	gioui.org v0.8.0
+	golang.org/x/crypto v0.48.0
+)
 
 require (
-	filippo.io/edwards25519 v1.2.0 // indirect
 	gioui.org/shader v1.0.8 // indirect
 	github.com/go-text/typesetting v0.2.1 // indirect
-	golang.org/x/crypto v0.48.0 // indirect
 	golang.org/x/exp v0.0.0-20240707233637-46b078467d37 // indirect
 	golang.org/x/exp/shiny v0.0.0-20240707233637-46b078467d37 // indirect
 	golang.org/x/image v0.18.0 // indirect
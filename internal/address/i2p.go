@@ -1,19 +1,47 @@
 package address
 
-import "github.com/go-i2p/i2p-vanitygen/internal/destination"
+import (
+	"github.com/go-i2p/i2p-vanitygen/internal/base32check"
+	"github.com/go-i2p/i2p-vanitygen/internal/destination"
+)
 
 // I2PScheme implements Scheme for I2P .b32.i2p addresses.
-type I2PScheme struct{}
+type I2PScheme struct {
+	// X25519 makes new candidates carry a real X25519 encryption key
+	// (crypto type 4) instead of a random ElGamal placeholder.
+	X25519 bool
+}
+
+func (I2PScheme) Network() Network                     { return NetworkI2P }
+func (I2PScheme) Suffix() string                       { return ".b32.i2p" }
+func (s I2PScheme) ValidatePrefix(prefix string) error { return validatePrefix(s, prefix) }
+func (s I2PScheme) EstimateAttempts(prefix string) float64 {
+	return EstimateSearchAttempts(s, prefix, "")
+}
+func (I2PScheme) MaxPrefixLen() int { return 52 }
+func (I2PScheme) SupportsGPU() bool { return true }
 
-func (I2PScheme) Network() Network                   { return NetworkI2P }
-func (I2PScheme) Suffix() string                     { return ".b32.i2p" }
-func (I2PScheme) ValidatePrefix(prefix string) error { return destination.ValidatePrefix(prefix) }
-func (I2PScheme) EstimateAttempts(n int) float64     { return destination.EstimateAttempts(n) }
-func (I2PScheme) MaxPrefixLen() int                  { return 52 }
-func (I2PScheme) SupportsGPU() bool                  { return true }
+func (s I2PScheme) ValidateSuffix(suffix string) error {
+	return validateSuffix(s, suffix, func(int) string {
+		return "the last character of a .b32.i2p address holds a single bit of the hash and is always 'a' or 'q'"
+	})
+}
+
+// Symbols reports that the 52nd character carries one hash bit followed by
+// four zero padding bits, so it is always 'a' or 'q'.
+func (I2PScheme) Symbols(pos int) uint32 {
+	if pos == 51 {
+		return 1<<0 | 1<<16
+	}
+	return anySymbol
+}
 
-func (I2PScheme) NewCandidate() (Candidate, error) {
-	d, err := destination.NewRandom()
+func (s I2PScheme) NewCandidate() (Candidate, error) {
+	newDest := destination.NewRandom
+	if s.X25519 {
+		newDest = destination.NewX25519
+	}
+	d, err := newDest()
 	if err != nil {
 		return nil, err
 	}
@@ -23,16 +51,66 @@ func (I2PScheme) NewCandidate() (Candidate, error) {
 // I2PCandidate wraps a destination.Destination to implement Candidate.
 type I2PCandidate struct {
 	Dest *destination.Destination
+
+	hash [32]byte // scratch space that Matchers read, so it never escapes per call
 }
 
 func (c *I2PCandidate) Address() string            { return c.Dest.B32Address() }
 func (c *I2PCandidate) FullAddress() string        { return c.Dest.FullB32Address() }
 func (c *I2PCandidate) SaveKeys(path string) error { return c.Dest.SaveKeys(path) }
 
-// MutateAndCheck mutates the encryption key with the given counter and checks the prefix.
-func (c *I2PCandidate) MutateAndCheck(counter uint64, prefix string) bool {
-	c.Dest.MutateEncryptionKey(counter)
-	return c.Dest.HasB32Prefix(prefix)
+// MutateAndCheck mutates the destination padding with the given counter and checks the prefix.
+func (c *I2PCandidate) MutateAndCheck(counter uint64, prefix *base32check.PrefixMask) bool {
+	c.Dest.MutatePadding(counter)
+	return c.Dest.HasB32PrefixMask(prefix)
+}
+
+// MutateAndCheckLen is MutateAndCheck returning how many leading characters
+// of the address match; the prefix matches when that is prefix.Len().
+func (c *I2PCandidate) MutateAndCheckLen(counter uint64, prefix *base32check.PrefixMask) int {
+	c.Dest.MutatePadding(counter)
+	return c.Dest.B32PrefixMaskLen(prefix)
+}
+
+// MutateAndMatch is MutateAndCheck for a prefix pattern that also requires
+// the address to end with suffix.
+func (c *I2PCandidate) MutateAndMatch(counter uint64, prefix base32check.Pattern, suffix string) bool {
+	c.Dest.MutatePadding(counter)
+	return c.Dest.MatchesB32(prefix, suffix)
+}
+
+// MutateAndMatchLen is MutateAndMatch that also returns how many leading
+// characters of the address match prefix.
+func (c *I2PCandidate) MutateAndMatchLen(counter uint64, prefix base32check.Pattern, suffix string) (int, bool) {
+	c.Dest.MutatePadding(counter)
+	return c.Dest.MatchB32Len(prefix, suffix)
+}
+
+// MutateAndMatchWith mutates the destination padding with the given counter
+// and passes the destination hash to m.
+func (c *I2PCandidate) MutateAndMatchWith(counter uint64, m Matcher) bool {
+	c.Dest.MutatePadding(counter)
+	return c.MatchWith(m)
+}
+
+// MatchWith passes the hash of the current destination to m.
+func (c *I2PCandidate) MatchWith(m Matcher) bool {
+	c.hash = c.Dest.B32Hash()
+	return m.Match(c.hash[:])
+}
+
+// MutateAndMatchTargets mutates the destination padding with the given counter
+// and appends the indices of the matching trie prefixes to out.
+func (c *I2PCandidate) MutateAndMatchTargets(counter uint64, t *base32check.PrefixTrie, suffix string, out []int) []int {
+	c.Dest.MutatePadding(counter)
+	return c.Dest.MatchB32Targets(t, suffix, out)
+}
+
+// Clone returns a copy of the candidate that is not affected by further
+// mutation of the original. The signing key is shared, as it never changes.
+func (c *I2PCandidate) Clone() *I2PCandidate {
+	d := *c.Dest
+	return &I2PCandidate{Dest: &d}
 }
 
 // Raw returns the raw destination bytes (needed for GPU worker template).
@@ -1,6 +1,10 @@
 package address
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/base32check"
+)
 
 var sinkI2PMatch bool
 
@@ -10,7 +14,7 @@ func BenchmarkI2PMutateAndCheck(b *testing.B) {
 		b.Fatal(err)
 	}
 	cand := candAny.(*I2PCandidate)
-	prefix := "abcde"
+	prefix := base32check.MustPrefixMask("abcde")
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
@@ -0,0 +1,39 @@
+package address
+
+import "github.com/go-i2p/i2p-vanitygen/internal/base32check"
+
+// Matcher decides whether a candidate address is accepted. It is given the
+// raw bytes whose lowercase base32 encoding is the address, so it can test
+// characters without encoding them (see base32check.Symbol): the 32-byte
+// SHA-256 of the destination for I2P, and the 35-byte public key, checksum
+// and version for Tor v3. The generator calls Match from every worker at
+// once, so it must be safe for concurrent use, and data is only valid until
+// Match returns.
+//
+// base32check.PrefixMask, base32check.Pattern and dfa.DFA are Matchers.
+type Matcher interface {
+	Match(data []byte) bool
+}
+
+// MatcherFunc adapts an ordinary function to Matcher.
+type MatcherFunc func(data []byte) bool
+
+// Match calls f(data).
+func (f MatcherFunc) Match(data []byte) bool { return f(data) }
+
+// PrefixMatcher returns the Matcher for a prefix pattern such as "shop" or
+// "??cafe", as validated by Scheme.ValidatePrefix.
+func PrefixMatcher(prefix string) (Matcher, error) {
+	if base32check.IsLiteral(prefix) {
+		m, err := base32check.NewPrefixMask(prefix)
+		if err != nil {
+			return nil, err
+		}
+		return m, nil
+	}
+	p, err := base32check.ParsePattern(prefix)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
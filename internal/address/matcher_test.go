@@ -0,0 +1,66 @@
+package address
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/base32check"
+)
+
+func TestMatchWithSeesTheAddress(t *testing.T) {
+	// A Matcher that rebuilds the address from the raw bytes it is given.
+	var seen string
+	record := MatcherFunc(func(data []byte) bool {
+		var b strings.Builder
+		for i := 0; i < (len(data)*8+4)/5; i++ {
+			b.WriteByte(base32check.Alphabet[base32check.Symbol(data, i)])
+		}
+		seen = b.String()
+		return true
+	})
+
+	i2p, err := I2PScheme{}.NewCandidate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ic := i2p.(*I2PCandidate)
+	if !ic.MutateAndMatchWith(3, record) || seen != ic.Address() {
+		t.Errorf("i2p: matcher saw %s, address is %s", seen, ic.Address())
+	}
+
+	tor, err := NewTorV3Candidate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tor.MatchWith(record) || seen != tor.Address() {
+		t.Errorf("torv3: matcher saw %s, address is %s", seen, tor.Address())
+	}
+}
+
+func TestPrefixMatcher(t *testing.T) {
+	data := []byte("foo") // "mzxw6"
+	tests := []struct {
+		prefix string
+		want   bool
+	}{
+		{"mzx", true},
+		{"MZXW6", true},
+		{"mzy", false},
+		{"?z[u-x]", true},
+		{"?z[a-e]", false},
+	}
+	for _, tt := range tests {
+		m, err := PrefixMatcher(tt.prefix)
+		if err != nil {
+			t.Fatalf("PrefixMatcher(%q): %v", tt.prefix, err)
+		}
+		if got := m.Match(data); got != tt.want {
+			t.Errorf("PrefixMatcher(%q).Match = %v, want %v", tt.prefix, got, tt.want)
+		}
+	}
+	for _, prefix := range []string{"ab1", "a[b"} {
+		if m, err := PrefixMatcher(prefix); err == nil || m != nil {
+			t.Errorf("PrefixMatcher(%q) = %v, %v; want an error", prefix, m, err)
+		}
+	}
+}
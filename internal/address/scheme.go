@@ -1,5 +1,16 @@
 package address
 
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"slices"
+	"strings"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/base32check"
+	"github.com/go-i2p/i2p-vanitygen/internal/dfa"
+)
+
 // Network identifies which overlay network an address belongs to.
 type Network int
 
@@ -29,6 +40,19 @@ func ParseNetwork(s string) Network {
 	}
 }
 
+// LookupScheme returns the Scheme for a network name as typed by a user.
+// Unlike ParseNetwork it rejects unknown names instead of defaulting to I2P.
+func LookupScheme(name string) (Scheme, error) {
+	switch strings.ToLower(name) {
+	case "i2p", "b32":
+		return I2PScheme{}, nil
+	case "torv3", "tor", "onion":
+		return TorV3Scheme{}, nil
+	default:
+		return nil, fmt.Errorf("unknown network %q (allowed: i2p, torv3)", name)
+	}
+}
+
 // Candidate represents a generated keypair and its associated address.
 type Candidate interface {
 	// Address returns the base32 address without the network suffix.
@@ -43,9 +67,214 @@ type Candidate interface {
 type Scheme interface {
 	Network() Network
 	Suffix() string
+	// ValidatePrefix checks a vanity prefix, which may be a pattern with
+	// wildcards and classes (see base32check.ParsePattern).
 	ValidatePrefix(prefix string) error
-	EstimateAttempts(prefixLen int) float64
+	// ValidateSuffix checks a vanity suffix, i.e. the characters just before
+	// Suffix(). Some trailing characters are fixed by the encoding, so not
+	// every suffix can occur.
+	ValidateSuffix(suffix string) error
+	// EstimateAttempts returns the average number of attempts needed to
+	// find an address matching prefix.
+	EstimateAttempts(prefix string) float64
 	MaxPrefixLen() int
+	// Symbols returns the set of base32 values (bit v for
+	// base32check.Alphabet[v]) that can occur at character pos of an address.
+	Symbols(pos int) uint32
 	NewCandidate() (Candidate, error)
 	SupportsGPU() bool
 }
+
+// anySymbol is the Symbols set of a position that can hold every character.
+const anySymbol = 1<<32 - 1
+
+// ValidateSearch checks a prefix/suffix pair for s: at least one must be set,
+// each must be valid on its own, and together they must fit in an address.
+func ValidateSearch(s Scheme, prefix, suffix string) error {
+	if prefix == "" && suffix == "" {
+		return fmt.Errorf("a prefix or a suffix is required")
+	}
+	var p base32check.Pattern
+	if prefix != "" {
+		if err := s.ValidatePrefix(prefix); err != nil {
+			return err
+		}
+		p = base32check.MustParsePattern(prefix)
+	}
+	if err := s.ValidateSuffix(suffix); err != nil {
+		return err
+	}
+	if len(p)+len(suffix) > s.MaxPrefixLen() {
+		return fmt.Errorf("prefix and suffix together cannot exceed %d characters", s.MaxPrefixLen())
+	}
+	return nil
+}
+
+// EstimateSearchAttempts returns the average number of attempts needed to
+// find an address with both prefix and suffix. It uses the true probability
+// of each position: the characters a pattern accepts there out of those that
+// can occur there at all.
+func EstimateSearchAttempts(s Scheme, prefix, suffix string) float64 {
+	p, err := base32check.ParsePattern(prefix)
+	if err != nil {
+		return math.Inf(1)
+	}
+	suffix = strings.ToLower(suffix)
+	odds := 1.0
+	for i, set := range p {
+		odds *= setOdds(s.Symbols(i), set)
+	}
+	start := s.MaxPrefixLen() - len(suffix)
+	for i := 0; i < len(suffix); i++ {
+		odds *= symbolOdds(s.Symbols(start+i), suffix[i])
+	}
+	if odds <= 2 {
+		return 1
+	}
+	return odds / 2
+}
+
+// ValidateRegex checks that expr compiles (see package dfa) and that it can
+// match some address of s but not every one.
+func ValidateRegex(s Scheme, expr string) error {
+	d, err := dfa.Compile(expr)
+	if err != nil {
+		return err
+	}
+	switch p := d.Probability(s.Symbols, s.MaxPrefixLen()); {
+	case p == 0:
+		return fmt.Errorf("expression can never match a %d-character %s address", s.MaxPrefixLen(), s.Suffix())
+	case p >= 1:
+		return fmt.Errorf("expression matches every address")
+	}
+	return nil
+}
+
+// EstimateRegexAttempts returns the average number of attempts needed to
+// find an address matching expr, from the exact probability of a match.
+func EstimateRegexAttempts(s Scheme, expr string) float64 {
+	d, err := dfa.Compile(expr)
+	if err != nil {
+		return math.Inf(1)
+	}
+	p := d.Probability(s.Symbols, s.MaxPrefixLen())
+	if p >= 0.5 {
+		return 1
+	}
+	return 1 / p / 2
+}
+
+// ValidateContains checks a word for a contains search: it must be a valid
+// base32 word that can occur somewhere in an address of s.
+func ValidateContains(s Scheme, word string) error {
+	if _, err := base32check.NewSubstring(word); err != nil {
+		return err
+	}
+	if containsProbability(s, word) == 0 {
+		return fmt.Errorf("%q can never occur in a%s address", word, s.Suffix())
+	}
+	return nil
+}
+
+// EstimateContainsAttempts returns the average number of attempts needed to
+// find an address containing word at any offset. The probability accounts
+// for every position the word fits in and for overlapping occurrences.
+func EstimateContainsAttempts(s Scheme, word string) float64 {
+	p := containsProbability(s, word)
+	if p >= 0.5 {
+		return 1
+	}
+	return 1 / p / 2
+}
+
+func containsProbability(s Scheme, word string) float64 {
+	d, err := dfa.Compile(strings.ToLower(word))
+	if err != nil {
+		return 0
+	}
+	return d.Probability(s.Symbols, s.MaxPrefixLen())
+}
+
+// EstimateTargetsAttempts returns the average number of attempts a single
+// search needs to match every prefix in prefixes (each with suffix). It
+// assumes the easiest remaining prefix is always the next one matched, which
+// is exact when all prefixes are equally likely.
+func EstimateTargetsAttempts(s Scheme, prefixes []string, suffix string) float64 {
+	rates := make([]float64, len(prefixes))
+	for i, p := range prefixes {
+		rates[i] = 1 / EstimateSearchAttempts(s, p, suffix)
+	}
+	slices.Sort(rates)
+	total, rate := 0.0, 0.0
+	for _, r := range rates {
+		// Walking from the rarest prefix up, rate is the combined rate of
+		// the prefixes still unmatched when this one is found.
+		rate += r
+		total += 1 / rate
+	}
+	return total
+}
+
+// symbolOdds returns 1/P(c) for a uniformly random character from set.
+func symbolOdds(set uint32, c byte) float64 {
+	return setOdds(set, symbolBit(c))
+}
+
+// setOdds returns 1/P(x in want) for a uniformly random character x from set.
+func setOdds(set, want uint32) float64 {
+	hits := bits.OnesCount32(set & want)
+	if hits == 0 {
+		return math.Inf(1)
+	}
+	return float64(bits.OnesCount32(set)) / float64(hits)
+}
+
+// validatePrefix checks that prefix is a non-empty pattern that fits in an
+// address of s and can match at every position.
+func validatePrefix(s Scheme, prefix string) error {
+	if len(prefix) == 0 {
+		return fmt.Errorf("prefix cannot be empty")
+	}
+	p, err := base32check.ParsePattern(prefix)
+	if err != nil {
+		return err
+	}
+	if len(p) > s.MaxPrefixLen() {
+		return fmt.Errorf("prefix cannot exceed %d characters", s.MaxPrefixLen())
+	}
+	for i, set := range p {
+		if s.Symbols(i)&set == 0 {
+			return fmt.Errorf("position %d of the prefix can never match a%s address", i+1, s.Suffix())
+		}
+	}
+	return nil
+}
+
+// symbolBit returns the Symbols bit for a lowercase base32 character, or 0.
+func symbolBit(c byte) uint32 {
+	v := strings.IndexByte(base32check.Alphabet, c)
+	if v < 0 {
+		return 0
+	}
+	return 1 << v
+}
+
+// validateSuffix checks suffix's characters and length and that each
+// character can occur at its position; explain describes a fixed position.
+func validateSuffix(s Scheme, suffix string, explain func(pos int) string) error {
+	if len(suffix) > s.MaxPrefixLen() {
+		return fmt.Errorf("suffix cannot exceed %d characters", s.MaxPrefixLen())
+	}
+	suffix = strings.ToLower(suffix)
+	start := s.MaxPrefixLen() - len(suffix)
+	for i := 0; i < len(suffix); i++ {
+		c := suffix[i]
+		if symbolBit(c) == 0 {
+			return fmt.Errorf("invalid character '%c' at position %d (allowed: a-z, 2-7)", c, i)
+		}
+		if s.Symbols(start+i)&symbolBit(c) == 0 {
+			return fmt.Errorf("suffix %q is impossible: %s", suffix, explain(start+i))
+		}
+	}
+	return nil
+}
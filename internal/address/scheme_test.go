@@ -0,0 +1,148 @@
+package address
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/base32check"
+)
+
+func TestSymbolsCoverGeneratedAddresses(t *testing.T) {
+	for _, s := range []Scheme{I2PScheme{}, TorV3Scheme{}} {
+		for n := 0; n < 64; n++ {
+			c, err := s.NewCandidate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			addr := c.Address()
+			if len(addr) != s.MaxPrefixLen() {
+				t.Fatalf("%s: address %s has %d characters, want %d", s.Network(), addr, len(addr), s.MaxPrefixLen())
+			}
+			for i := 0; i < len(addr); i++ {
+				if s.Symbols(i)&symbolBit(addr[i]) == 0 {
+					t.Fatalf("%s: address %s has '%c' at position %d, outside Symbols", s.Network(), addr, addr[i], i)
+				}
+			}
+		}
+	}
+}
+
+func TestSuffixMatching(t *testing.T) {
+	i2p, err := I2PScheme{}.NewCandidate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ic := i2p.(*I2PCandidate)
+	ic.MutateAndCheck(7, base32check.MustPrefixMask(""))
+	addr := ic.Address()
+	if !ic.MutateAndMatch(7, base32check.MustParsePattern(addr[:2]), addr[len(addr)-3:]) {
+		t.Errorf("MutateAndMatch(%q, %q) = false for %s", addr[:2], addr[len(addr)-3:], addr)
+	}
+	if ic.MutateAndMatch(7, nil, flip(addr[len(addr)-3:])) {
+		t.Errorf("MutateAndMatch matched a wrong suffix for %s", addr)
+	}
+
+	tor, err := NewTorV3Candidate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr = tor.Address()
+	if !tor.Matches(base32check.MustParsePattern(addr[:2]), addr[len(addr)-4:]) {
+		t.Errorf("Matches(%q, %q) = false for %s", addr[:2], addr[len(addr)-4:], addr)
+	}
+	if tor.Matches(nil, flip(addr[len(addr)-4:])) {
+		t.Errorf("Matches accepted a wrong suffix for %s", addr)
+	}
+}
+
+// flip changes the first character of s to a different base32 character.
+func flip(s string) string {
+	c := 'a'
+	if s[0] == 'a' {
+		c = 'b'
+	}
+	return string(c) + s[1:]
+}
+
+func TestValidateSearch(t *testing.T) {
+	tests := []struct {
+		scheme  Scheme
+		prefix  string
+		suffix  string
+		wantErr string
+	}{
+		{I2PScheme{}, "abc", "", ""},
+		{I2PScheme{}, "", "xyzq", ""},
+		{I2PScheme{}, "ab", "CDa", ""},
+		{I2PScheme{}, "", "", "prefix or a suffix"},
+		{I2PScheme{}, "", "xyzb", "impossible"},
+		{I2PScheme{}, "", "ab1", "invalid character"},
+		{I2PScheme{}, strings.Repeat("a", 30), strings.Repeat("a", 30), "together"},
+		{I2PScheme{}, "??cafe", "", ""},
+		{I2PScheme{}, "[a-e]x[2-7]", "q", ""},
+		{I2PScheme{}, "ab[c", "", "unterminated"},
+		{I2PScheme{}, strings.Repeat("?", 51) + "[b-p]", "", "never match"},
+		{I2PScheme{}, strings.Repeat("?", 51) + "[a-c]", "", ""},
+		{TorV3Scheme{}, "", "ad", ""},
+		{TorV3Scheme{}, "", "yd", ""},
+		{TorV3Scheme{}, "", "bd", "impossible"},
+		{TorV3Scheme{}, "", "aa", "impossible"},
+	}
+	for _, tt := range tests {
+		err := ValidateSearch(tt.scheme, tt.prefix, tt.suffix)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s %q/%q: unexpected error: %v", tt.scheme.Network(), tt.prefix, tt.suffix, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s %q/%q: error %v, want one containing %q", tt.scheme.Network(), tt.prefix, tt.suffix, err, tt.wantErr)
+		}
+	}
+}
+
+func TestEstimateSearchAttempts(t *testing.T) {
+	s := TorV3Scheme{}
+	if got, want := s.EstimateAttempts("abc"), 32.0*32*32/2; got != want {
+		t.Errorf("prefix only: got %v, want %v", got, want)
+	}
+	// Wildcards are free and a class of n characters costs 32/n.
+	if got, want := s.EstimateAttempts("??cafe"), 32.0*32*32*32/2; got != want {
+		t.Errorf("\"??cafe\": got %v, want %v", got, want)
+	}
+	if got, want := s.EstimateAttempts("[a-d]?[2-7]"), 8.0*32/6/2; got != want {
+		t.Errorf("\"[a-d]?[2-7]\": got %v, want %v", got, want)
+	}
+	// 'd' is certain and 'a' is one of four, so "ad" costs a factor of 4.
+	if got := EstimateSearchAttempts(s, "", "ad"); got != 2 {
+		t.Errorf("suffix \"ad\": got %v, want 2", got)
+	}
+	if got := EstimateSearchAttempts(s, "", "bd"); !math.IsInf(got, 1) {
+		t.Errorf("impossible suffix: got %v, want +Inf", got)
+	}
+	if got, want := EstimateSearchAttempts(I2PScheme{}, "a", "bq"), 32.0*32*2/2; got != want {
+		t.Errorf("i2p prefix and suffix: got %v, want %v", got, want)
+	}
+}
+
+func TestEstimateContainsAttempts(t *testing.T) {
+	s := I2PScheme{}
+	// A 4-character word fits at 49 offsets, but the last would put 'p' in
+	// the final character, which is always 'a' or 'q'. Overlaps are rare
+	// enough that the exact answer is within a percent of 32^4/48/2.
+	got := EstimateContainsAttempts(s, "shop")
+	if want := 32.0 * 32 * 32 * 32 / 48 / 2; math.Abs(got-want)/want > 0.01 {
+		t.Errorf("contains \"shop\": got %v, want about %v", got, want)
+	}
+	if got, prefix := EstimateContainsAttempts(s, "shop"), s.EstimateAttempts("shop"); got >= prefix {
+		t.Errorf("contains (%v) should be cheaper than prefix (%v)", got, prefix)
+	}
+	if err := ValidateContains(TorV3Scheme{}, "dd"); err != nil {
+		t.Errorf("\"dd\" can end an onion address: %v", err)
+	}
+	if err := ValidateContains(TorV3Scheme{}, "bd"); err != nil {
+		t.Errorf("\"bd\" can occur before the end of an onion address: %v", err)
+	}
+	if err := ValidateContains(I2PScheme{}, "a1"); err == nil {
+		t.Error("ValidateContains accepted an invalid character")
+	}
+}
@@ -19,6 +19,12 @@ import (
 var onionEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
 var torV3ChecksumPrefix = [15]byte{'.', 'o', 'n', 'i', 'o', 'n', ' ', 'c', 'h', 'e', 'c', 'k', 's', 'u', 'm'}
 
+// Headers of the Tor hidden service key files (32 bytes each, NUL padded).
+const (
+	TorV3SecretKeyHeader = "== ed25519v1-secret: type0 ==\x00\x00\x00"
+	TorV3PublicKeyHeader = "== ed25519v1-public: type0 ==\x00\x00\x00"
+)
+
 // TorV3Scheme implements Scheme for Tor v3 .onion addresses.
 type TorV3Scheme struct{}
 
@@ -27,31 +33,34 @@ func (TorV3Scheme) Suffix() string    { return ".onion" }
 func (TorV3Scheme) MaxPrefixLen() int { return 56 }
 func (TorV3Scheme) SupportsGPU() bool { return true }
 
-func (TorV3Scheme) ValidatePrefix(prefix string) error {
-	if len(prefix) == 0 {
-		return fmt.Errorf("prefix cannot be empty")
-	}
-	if len(prefix) > 56 {
-		return fmt.Errorf("prefix cannot exceed 56 characters")
-	}
-	prefix = strings.ToLower(prefix)
-	for i, c := range prefix {
-		if !((c >= 'a' && c <= 'z') || (c >= '2' && c <= '7')) {
-			return fmt.Errorf("invalid character '%c' at position %d (allowed: a-z, 2-7)", c, i)
+func (s TorV3Scheme) ValidatePrefix(prefix string) error {
+	return validatePrefix(s, prefix)
+}
+
+func (s TorV3Scheme) ValidateSuffix(suffix string) error {
+	return validateSuffix(s, suffix, func(pos int) string {
+		if pos == 55 {
+			return "every onion address ends in 'd' (the version byte)"
 		}
-	}
-	return nil
+		return "the second-to-last character of an onion address is always a, i, q or y (its low bits belong to the version byte)"
+	})
 }
 
-func (TorV3Scheme) EstimateAttempts(prefixLen int) float64 {
-	if prefixLen <= 0 {
-		return 1
-	}
-	attempts := 1.0
-	for i := 0; i < prefixLen; i++ {
-		attempts *= 32
+// Symbols reports the characters fixed by the version byte 0x03 at the end
+// of the payload: the last character is always 'd' and the one before it
+// has three zero low bits.
+func (TorV3Scheme) Symbols(pos int) uint32 {
+	switch pos {
+	case 54:
+		return 1<<0 | 1<<8 | 1<<16 | 1<<24
+	case 55:
+		return 1 << 3
 	}
-	return attempts / 2
+	return anySymbol
+}
+
+func (s TorV3Scheme) EstimateAttempts(prefix string) float64 {
+	return EstimateSearchAttempts(s, prefix, "")
 }
 
 func (TorV3Scheme) NewCandidate() (Candidate, error) {
@@ -73,6 +82,8 @@ type TorV3Candidate struct {
 	// Precomputed
 	oneScalar *edwards25519.Scalar // scalar = 1
 	genPoint  *edwards25519.Point  // generator point G
+
+	payload [35]byte // scratch space that Matchers read
 }
 
 // NewTorV3Candidate creates a new candidate with a random Ed25519 keypair.
@@ -111,6 +122,69 @@ func NewTorV3Candidate() (*TorV3Candidate, error) {
 	return c, nil
 }
 
+// TorV3CandidateFromExpanded rebuilds a candidate from the 64-byte expanded
+// key stored in hs_ed25519_secret_key (scalar followed by the nonce half of
+// SHA-512). The original seed is not recoverable and is left zero.
+func TorV3CandidateFromExpanded(expanded []byte) (*TorV3Candidate, error) {
+	if len(expanded) != 64 {
+		return nil, fmt.Errorf("expanded key must be 64 bytes, got %d", len(expanded))
+	}
+
+	// Reduce the scalar mod l; Tor's own keys are clamped and not canonical.
+	var wide [64]byte
+	copy(wide[:32], expanded[:32])
+	scalar, err := edwards25519.NewScalar().SetUniformBytes(wide[:])
+	if err != nil {
+		return nil, fmt.Errorf("decoding scalar: %w", err)
+	}
+
+	var oneBuf [32]byte
+	oneBuf[0] = 1
+	oneScalar, _ := edwards25519.NewScalar().SetCanonicalBytes(oneBuf[:])
+
+	c := &TorV3Candidate{
+		scalar:    scalar,
+		point:     new(edwards25519.Point).ScalarBaseMult(scalar),
+		oneScalar: oneScalar,
+		genPoint:  edwards25519.NewGeneratorPoint(),
+	}
+	copy(c.hashSuffix[:], expanded[32:])
+	return c, nil
+}
+
+// OnionAddress returns the 56-character base32 onion address (without .onion
+// suffix) for a 32-byte Ed25519 public key.
+func OnionAddress(pubkey []byte) string {
+	var payload [35]byte
+	copy(payload[:32], pubkey)
+	checksum := torV3Checksum(pubkey)
+	payload[32] = checksum[0]
+	payload[33] = checksum[1]
+	payload[34] = 0x03
+	return onionEncoding.EncodeToString(payload[:])
+}
+
+// ParseOnionAddress decodes a v3 onion address (with or without the .onion
+// suffix), verifies its version and checksum and returns the Ed25519 public key.
+func ParseOnionAddress(addr string) (ed25519.PublicKey, error) {
+	addr = strings.TrimSuffix(strings.ToLower(addr), ".onion")
+	if len(addr) != 56 {
+		return nil, fmt.Errorf("onion address must be 56 characters, got %d", len(addr))
+	}
+	payload, err := onionEncoding.DecodeString(addr)
+	if err != nil {
+		return nil, fmt.Errorf("decoding onion address: %w", err)
+	}
+	if payload[34] != 0x03 {
+		return nil, fmt.Errorf("unsupported onion address version %d", payload[34])
+	}
+	checksum := torV3Checksum(payload[:32])
+	if payload[32] != checksum[0] || payload[33] != checksum[1] {
+		return nil, fmt.Errorf("onion address checksum mismatch")
+	}
+	return ed25519.PublicKey(payload[:32]), nil
+}
+
 // Address returns the 56-character base32 onion address (without .onion suffix).
 func (c *TorV3Candidate) Address() string {
 	var payload [35]byte
@@ -143,10 +217,52 @@ func (c *TorV3Candidate) AdvanceBy(n uint64) {
 }
 
 // CheckPrefix checks whether the current address starts with the given prefix.
-func (c *TorV3Candidate) CheckPrefix(prefix string) bool {
+func (c *TorV3Candidate) CheckPrefix(prefix *base32check.PrefixMask) bool {
+	var payload [35]byte
+	c.buildAddressPayload(&payload)
+	return prefix.Match(payload[:])
+}
+
+// CheckPrefixLen returns how many leading characters of the current address
+// match the prefix; it matches when that is prefix.Len().
+func (c *TorV3Candidate) CheckPrefixLen(prefix *base32check.PrefixMask) int {
 	var payload [35]byte
 	c.buildAddressPayload(&payload)
-	return base32check.HasPrefixLowerNoPad(payload[:], prefix)
+	return prefix.MatchLen(payload[:])
+}
+
+// Matches checks whether the current address matches the prefix pattern and
+// ends with suffix.
+func (c *TorV3Candidate) Matches(prefix base32check.Pattern, suffix string) bool {
+	var payload [35]byte
+	c.buildAddressPayload(&payload)
+	return prefix.Match(payload[:]) && base32check.HasSuffixLowerNoPad(payload[:], suffix)
+}
+
+// MatchLen is Matches that also returns how many leading characters of the
+// current address match prefix.
+func (c *TorV3Candidate) MatchLen(prefix base32check.Pattern, suffix string) (int, bool) {
+	var payload [35]byte
+	c.buildAddressPayload(&payload)
+	n := prefix.MatchLen(payload[:])
+	return n, n == len(prefix) && base32check.HasSuffixLowerNoPad(payload[:], suffix)
+}
+
+// MatchWith passes the current address payload to m.
+func (c *TorV3Candidate) MatchWith(m Matcher) bool {
+	c.buildAddressPayload(&c.payload)
+	return m.Match(c.payload[:])
+}
+
+// MatchTargets appends to out the indices of the trie prefixes the current
+// address starts with, provided it also ends with suffix.
+func (c *TorV3Candidate) MatchTargets(t *base32check.PrefixTrie, suffix string, out []int) []int {
+	var payload [35]byte
+	c.buildAddressPayload(&payload)
+	if !base32check.HasSuffixLowerNoPad(payload[:], suffix) {
+		return out
+	}
+	return t.Match(payload[:], out)
 }
 
 func (c *TorV3Candidate) buildAddressPayload(payload *[35]byte) {
@@ -169,18 +285,17 @@ func (c *TorV3Candidate) SaveKeys(dir string) error {
 
 	// hs_ed25519_secret_key: 32-byte header + 64-byte expanded key
 	// The expanded key is: clamped scalar (32) + nonce hash suffix (32)
-	secretHeader := []byte("== ed25519v1-secret: type0 ==\x00\x00\x00")
+	secretHeader := []byte(TorV3SecretKeyHeader)
 	secretKey := make([]byte, 0, len(secretHeader)+64)
 	secretKey = append(secretKey, secretHeader...)
-	secretKey = append(secretKey, c.scalar.Bytes()...)
-	secretKey = append(secretKey, c.hashSuffix[:]...)
+	secretKey = append(secretKey, c.SecretKeyBytes()...)
 
 	if err := os.WriteFile(filepath.Join(dir, "hs_ed25519_secret_key"), secretKey, 0600); err != nil {
 		return fmt.Errorf("writing secret key: %w", err)
 	}
 
 	// hs_ed25519_public_key: 32-byte header + 32-byte public key
-	pubHeader := []byte("== ed25519v1-public: type0 ==\x00\x00\x00")
+	pubHeader := []byte(TorV3PublicKeyHeader)
 	pubKey := make([]byte, 0, len(pubHeader)+32)
 	pubKey = append(pubKey, pubHeader...)
 	pubKey = append(pubKey, pubBytes...)
@@ -198,6 +313,14 @@ func (c *TorV3Candidate) SaveKeys(dir string) error {
 	return nil
 }
 
+// SecretKeyBytes returns the 64-byte expanded secret key in Tor's layout:
+// the current scalar followed by the nonce half of SHA-512(seed).
+func (c *TorV3Candidate) SecretKeyBytes() []byte {
+	key := make([]byte, 0, 64)
+	key = append(key, c.scalar.Bytes()...)
+	return append(key, c.hashSuffix[:]...)
+}
+
 // PublicKeyBytes returns the current 32-byte public key.
 func (c *TorV3Candidate) PublicKeyBytes() []byte {
 	return c.point.Bytes()
@@ -228,6 +351,33 @@ func (c *TorV3Candidate) ExpandedPrivateKey() ed25519.PrivateKey {
 	return priv
 }
 
+// Sign returns an Ed25519 signature of message made directly from the
+// expanded key, as Tor does: there is no seed, so the nonce is derived from
+// the second half of the expanded key. The signature verifies with
+// crypto/ed25519 against PublicKeyBytes.
+func (c *TorV3Candidate) Sign(message []byte) []byte {
+	pub := c.point.Bytes()
+
+	h := sha512.New()
+	h.Write(c.hashSuffix[:])
+	h.Write(message)
+	var digest [64]byte
+	r, _ := edwards25519.NewScalar().SetUniformBytes(h.Sum(digest[:0]))
+	R := new(edwards25519.Point).ScalarBaseMult(r).Bytes()
+
+	h.Reset()
+	h.Write(R)
+	h.Write(pub)
+	h.Write(message)
+	k, _ := edwards25519.NewScalar().SetUniformBytes(h.Sum(digest[:0]))
+
+	S := edwards25519.NewScalar().MultiplyAdd(k, c.scalar, r)
+
+	sig := make([]byte, 0, ed25519.SignatureSize)
+	sig = append(sig, R...)
+	return append(sig, S.Bytes()...)
+}
+
 // torV3Checksum computes the 2-byte checksum for a Tor v3 onion address.
 func torV3Checksum(pubkey []byte) [2]byte {
 	var input [48]byte
@@ -1,6 +1,10 @@
 package address
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/base32check"
+)
 
 var sinkTorMatch bool
 
@@ -9,7 +13,7 @@ func BenchmarkTorV3CheckPrefix(b *testing.B) {
 	if err != nil {
 		b.Fatal(err)
 	}
-	prefix := "abcde"
+	prefix := base32check.MustPrefixMask("abcde")
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
@@ -1,6 +1,8 @@
 package address
 
 import (
+	"bytes"
+	"crypto/ed25519"
 	"encoding/base32"
 	"strings"
 	"testing"
@@ -180,6 +182,52 @@ func TestTorV3PublicKeyConsistency(t *testing.T) {
 	}
 }
 
+func TestTorV3SignMatchesEd25519(t *testing.T) {
+	c, err := NewTorV3Candidate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("vanity")
+
+	// Signing from the expanded key must give the same (deterministic)
+	// signature crypto/ed25519 makes from the seed.
+	want := ed25519.Sign(ed25519.NewKeyFromSeed(c.seed[:]), msg)
+	if got := c.Sign(msg); !bytes.Equal(got, want) {
+		t.Fatalf("Sign = %x, want %x", got, want)
+	}
+
+	// After advancing there is no seed, but the signature must still verify.
+	c.AdvanceBy(12345)
+	if !ed25519.Verify(c.PublicKeyBytes(), msg, c.Sign(msg)) {
+		t.Fatal("signature from advanced key does not verify")
+	}
+}
+
+func TestParseOnionAddress(t *testing.T) {
+	c, err := NewTorV3Candidate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := ParseOnionAddress(c.FullAddress())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pub, c.PublicKeyBytes()) {
+		t.Fatalf("ParseOnionAddress = %x, want %x", pub, c.PublicKeyBytes())
+	}
+
+	// Flip one character so the checksum no longer matches.
+	addr := []byte(c.Address())
+	if addr[0] == 'a' {
+		addr[0] = 'b'
+	} else {
+		addr[0] = 'a'
+	}
+	if _, err := ParseOnionAddress(string(addr)); err == nil {
+		t.Fatal("ParseOnionAddress accepted a corrupted address")
+	}
+}
+
 func TestTorV3ValidatePrefix(t *testing.T) {
 	scheme := TorV3Scheme{}
 
@@ -0,0 +1,106 @@
+package base32check
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+// MaxMaskLen is the longest prefix a PrefixMask can hold.
+const MaxMaskLen = 64
+
+const maskWords = (MaxMaskLen*5 + 63) / 64
+
+// PrefixMask is a literal prefix compiled to the bits its base32 encoding
+// puts at the start of the raw data, so a candidate is checked with one
+// compare-and-mask per 64 bits of prefix (a single one for up to 12
+// characters) instead of decoding and comparing character by character.
+type PrefixMask struct {
+	value [maskWords]uint64
+	mask  [maskWords]uint64
+	words int
+	n     int
+}
+
+// NewPrefixMask compiles prefix, which must be up to MaxMaskLen base32
+// characters in either case.
+func NewPrefixMask(prefix string) (*PrefixMask, error) {
+	if len(prefix) > MaxMaskLen {
+		return nil, fmt.Errorf("prefix is longer than %d characters", MaxMaskLen)
+	}
+	m := &PrefixMask{n: len(prefix), words: (len(prefix)*5 + 63) / 64}
+	for i := 0; i < len(prefix); i++ {
+		v := alphabetIndex(lower(prefix[i]))
+		if v < 0 {
+			return nil, fmt.Errorf("invalid character '%c' at position %d (allowed: a-z, 2-7)", prefix[i], i)
+		}
+		for b := 0; b < 5; b++ {
+			pos := i*5 + b
+			bit := uint64(1) << (63 - pos%64)
+			m.mask[pos/64] |= bit
+			if v>>(4-b)&1 != 0 {
+				m.value[pos/64] |= bit
+			}
+		}
+	}
+	return m, nil
+}
+
+// MustPrefixMask is like NewPrefixMask but panics if prefix is invalid.
+func MustPrefixMask(prefix string) *PrefixMask {
+	m, err := NewPrefixMask(prefix)
+	if err != nil {
+		panic("base32check: " + err.Error())
+	}
+	return m
+}
+
+// Len returns the number of characters in the prefix.
+func (m *PrefixMask) Len() int { return m.n }
+
+// Match reports whether the lowercase base32 (RFC4648, no padding) encoding
+// of data starts with the prefix, like HasPrefixLowerNoPad.
+func (m *PrefixMask) Match(data []byte) bool {
+	if m.n > (len(data)*8+4)/5 {
+		return false
+	}
+	if m.words == 1 && len(data) >= 8 {
+		// Prefixes of up to 12 characters: a single compare.
+		return binary.BigEndian.Uint64(data)&m.mask[0] == m.value[0]
+	}
+	for i := 0; i < m.words; i++ {
+		if load(data, i)&m.mask[i] != m.value[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchLen returns how many leading characters of the encoding of data
+// match the prefix; the prefix matches exactly when that is Len. The first
+// differing bit gives the answer, so this costs no more than Match.
+func (m *PrefixMask) MatchLen(data []byte) int {
+	maxChars := (len(data)*8 + 4) / 5
+	for i := 0; i < m.words; i++ {
+		if x := (load(data, i) ^ m.value[i]) & m.mask[i]; x != 0 {
+			return min((i*64+bits.LeadingZeros64(x))/5, maxChars)
+		}
+	}
+	return min(m.n, maxChars)
+}
+
+// load returns the i-th big-endian 64-bit word of data. Bytes past the end
+// read as zero, the encoding's padding.
+func load(data []byte, i int) uint64 {
+	off := i * 8
+	if off+8 <= len(data) {
+		return binary.BigEndian.Uint64(data[off:])
+	}
+	return loadTail(data[min(off, len(data)):])
+}
+
+func loadTail(tail []byte) uint64 {
+	var buf [8]byte
+	copy(buf[:], tail)
+	return binary.BigEndian.Uint64(buf[:])
+}
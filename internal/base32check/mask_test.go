@@ -0,0 +1,106 @@
+package base32check
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	mrand "math/rand/v2"
+	"strings"
+	"testing"
+)
+
+func TestPrefixMaskAgreesWithHasPrefix(t *testing.T) {
+	encoding := base32.NewEncoding(Alphabet).WithPadding(base32.NoPadding)
+	for _, size := range []int{32, 35, 6} {
+		data := make([]byte, size)
+		for n := 0; n < 2000; n++ {
+			if _, err := rand.Read(data); err != nil {
+				t.Fatal(err)
+			}
+			s := encoding.EncodeToString(data)
+			// A prefix of the encoding, with its last character changed
+			// half of the time, and sometimes one character too long.
+			k := mrand.IntN(len(s) + 2)
+			prefix := (s + "a")[:min(k, len(s)+1)]
+			if k > 0 && k <= len(s) && n%2 == 0 {
+				prefix = prefix[:k-1] + string(Alphabet[mrand.IntN(32)])
+			}
+			if n%3 == 0 {
+				prefix = strings.ToUpper(prefix)
+			}
+
+			m := MustPrefixMask(prefix)
+			want := HasPrefixLowerNoPad(data, prefix)
+			if got := m.Match(data); got != want {
+				t.Fatalf("Match(%q) on %s = %v, want %v", prefix, s, got, want)
+			}
+			wantLen := 0
+			for wantLen < len(prefix) && HasPrefixLowerNoPad(data, prefix[:wantLen+1]) {
+				wantLen++
+			}
+			if got := m.MatchLen(data); got != wantLen {
+				t.Fatalf("MatchLen(%q) on %s = %d, want %d", prefix, s, got, wantLen)
+			}
+		}
+	}
+}
+
+func TestNewPrefixMaskErrors(t *testing.T) {
+	if _, err := NewPrefixMask("ab1"); err == nil || !strings.Contains(err.Error(), "invalid character '1'") {
+		t.Errorf("invalid character: error %v", err)
+	}
+	if _, err := NewPrefixMask(strings.Repeat("a", MaxMaskLen+1)); err == nil {
+		t.Error("overlong prefix accepted")
+	}
+}
+
+var sinkMatch bool
+
+func benchmarkPrefix(b *testing.B, prefix string, match func(data []byte) bool) {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		data[0] = byte(i) // vary the first character like real candidates
+		sinkMatch = match(data)
+	}
+}
+
+func BenchmarkHasPrefixLowerNoPad(b *testing.B) {
+	for _, prefix := range []string{"abcde", "abcdefghij"} {
+		b.Run(prefix, func(b *testing.B) {
+			benchmarkPrefix(b, prefix, func(data []byte) bool { return HasPrefixLowerNoPad(data, prefix) })
+		})
+	}
+}
+
+func BenchmarkPrefixMask(b *testing.B) {
+	for _, prefix := range []string{"abcde", "abcdefghij"} {
+		b.Run(prefix, func(b *testing.B) {
+			benchmarkPrefix(b, prefix, MustPrefixMask(prefix).Match)
+		})
+	}
+}
+
+// BenchmarkPrefixMaskFullCompare forces every character to be compared,
+// the worst case for the character-by-character check.
+func BenchmarkPrefixMaskFullCompare(b *testing.B) {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		b.Fatal(err)
+	}
+	encoding := base32.NewEncoding(Alphabet).WithPadding(base32.NoPadding)
+	prefix := encoding.EncodeToString(data)[:10]
+	b.Run("HasPrefixLowerNoPad", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sinkMatch = HasPrefixLowerNoPad(data, prefix)
+		}
+	})
+	b.Run("PrefixMask", func(b *testing.B) {
+		m := MustPrefixMask(prefix)
+		for i := 0; i < b.N; i++ {
+			sinkMatch = m.Match(data)
+		}
+	})
+}
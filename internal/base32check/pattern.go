@@ -0,0 +1,121 @@
+package base32check
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Pattern is a parsed prefix pattern: for each position, the set of base32
+// values it accepts, with bit v standing for Alphabet[v].
+type Pattern []uint32
+
+// AnyChar is the Pattern set of a '?' position.
+const AnyChar = 1<<32 - 1
+
+// IsLiteral reports whether s is a plain prefix without wildcards or classes.
+func IsLiteral(s string) bool {
+	return !strings.ContainsAny(s, "?[")
+}
+
+// ParsePattern parses a prefix pattern. Besides literal base32 characters
+// (in either case) it accepts '?' for any character and classes such as
+// [a-e], [2-7] or [aeiou], so "??cafe" matches "cafe" at characters 3-6.
+func ParsePattern(s string) (Pattern, error) {
+	p := make(Pattern, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		switch c := lower(s[i]); c {
+		case '?':
+			p = append(p, AnyChar)
+		case '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated character class at position %d", i)
+			}
+			set, err := parseClass(s[i+1:i+end], i+1)
+			if err != nil {
+				return nil, err
+			}
+			p = append(p, set)
+			i += end
+		default:
+			v := alphabetIndex(c)
+			if v < 0 {
+				return nil, fmt.Errorf("invalid character '%c' at position %d (allowed: a-z, 2-7, ? and classes like [a-e])", s[i], i)
+			}
+			p = append(p, 1<<v)
+		}
+	}
+	return p, nil
+}
+
+// MustParsePattern is like ParsePattern but panics if s is invalid.
+func MustParsePattern(s string) Pattern {
+	p, err := ParsePattern(s)
+	if err != nil {
+		panic("base32check: " + err.Error())
+	}
+	return p
+}
+
+// parseClass parses the inside of a [...] class starting at offset in the
+// pattern. Ranges run between two letters or two digits.
+func parseClass(class string, offset int) (uint32, error) {
+	if class == "" {
+		return 0, fmt.Errorf("empty character class at position %d", offset-1)
+	}
+	var set uint32
+	for i := 0; i < len(class); i++ {
+		lo := lower(class[i])
+		if alphabetIndex(lo) < 0 {
+			return 0, fmt.Errorf("invalid character '%c' in class at position %d (allowed: a-z, 2-7)", class[i], offset+i)
+		}
+		hi := lo
+		if i+2 < len(class) && class[i+1] == '-' {
+			hi = lower(class[i+2])
+			if alphabetIndex(hi) < 0 || isDigit(lo) != isDigit(hi) || hi < lo {
+				return 0, fmt.Errorf("invalid range %q in class at position %d", class[i:i+3], offset+i)
+			}
+			i += 2
+		}
+		for c := lo; c <= hi; c++ {
+			set |= 1 << alphabetIndex(c)
+		}
+	}
+	return set, nil
+}
+
+// Match reports whether the lowercase base32 (RFC4648, no padding) encoding
+// of data starts with a string matching p.
+func (p Pattern) Match(data []byte) bool {
+	if len(p) > (len(data)*8+4)/5 {
+		return false
+	}
+	for i, set := range p {
+		if set&(1<<Symbol(data, i)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchLen returns how many leading characters of the lowercase base32
+// encoding of data match p before the first mismatch, so it is len(p)
+// exactly when Match reports true.
+func (p Pattern) MatchLen(data []byte) int {
+	n := min(len(p), (len(data)*8+4)/5)
+	for i := 0; i < n; i++ {
+		if p[i]&(1<<Symbol(data, i)) == 0 {
+			return i
+		}
+	}
+	return n
+}
+
+func lower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + ('a' - 'A')
+	}
+	return c
+}
+
+func isDigit(c byte) bool { return c >= '2' && c <= '7' }
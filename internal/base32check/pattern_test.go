@@ -0,0 +1,91 @@
+package base32check
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    Pattern
+		wantErr string
+	}{
+		{"ab", Pattern{1 << 0, 1 << 1}, ""},
+		{"A?", Pattern{1 << 0, AnyChar}, ""},
+		{"[a-c7]", Pattern{1<<0 | 1<<1 | 1<<2 | 1<<31}, ""},
+		{"[2-3Z]", Pattern{1<<26 | 1<<27 | 1<<25}, ""},
+		{"", Pattern{}, ""},
+		{"a1", nil, "invalid character '1'"},
+		{"[ab", nil, "unterminated"},
+		{"[]", nil, "empty"},
+		{"[c-a]", nil, "invalid range"},
+		{"[x-3]", nil, "invalid range"},
+		{"[a-]", nil, "invalid character '-'"},
+	}
+	for _, tt := range tests {
+		got, err := ParsePattern(tt.pattern)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParsePattern(%q): error %v, want one containing %q", tt.pattern, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParsePattern(%q): %v", tt.pattern, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ParsePattern(%q) = %v, want %v", tt.pattern, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("ParsePattern(%q) = %v, want %v", tt.pattern, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestPatternMatch(t *testing.T) {
+	// "mzxw6" is the base32 encoding of "foo".
+	data := []byte("foo")
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{"mzxw6", true},
+		{"MZX", true},
+		{"??x", true},
+		{"??y", false},
+		{"[l-n]z[w-x]?[2-7]", true},
+		{"[2-7]", false},
+		{"mzxw6a", false}, // longer than the encoding
+	}
+	for _, tt := range tests {
+		if got := MustParsePattern(tt.pattern).Match(data); got != tt.want {
+			t.Errorf("%q.Match(%q) = %v, want %v", tt.pattern, data, got, tt.want)
+		}
+	}
+}
+
+func TestPatternMatchLen(t *testing.T) {
+	data := []byte("foo") // "mzxw6"
+	tests := []struct {
+		pattern string
+		want    int
+	}{
+		{"mzxw6", 5},
+		{"mzxaa", 3},
+		{"?z?q", 3},
+		{"a", 0},
+		{"", 0},
+		{"mzxw6a", 5}, // longer than the encoding
+	}
+	for _, tt := range tests {
+		if got := MustParsePattern(tt.pattern).MatchLen(data); got != tt.want {
+			t.Errorf("%q.MatchLen(%q) = %d, want %d", tt.pattern, data, got, tt.want)
+		}
+	}
+}
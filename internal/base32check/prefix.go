@@ -1,5 +1,8 @@
 package base32check
 
+// Alphabet is the lowercase RFC4648 base32 alphabet used by both networks.
+const Alphabet = "abcdefghijklmnopqrstuvwxyz234567"
+
 // HasPrefixLowerNoPad reports whether the lowercase base32 (RFC4648, no padding)
 // encoding of data starts with prefix. Prefix must be ASCII base32 chars.
 func HasPrefixLowerNoPad(data []byte, prefix string) bool {
@@ -39,3 +42,42 @@ func HasPrefixLowerNoPad(data []byte, prefix string) bool {
 
 	return true
 }
+
+// HasSuffixLowerNoPad reports whether the lowercase base32 (RFC4648, no padding)
+// encoding of data ends with suffix. When data does not fill the last
+// character, its low bits are the encoding's zero padding.
+func HasSuffixLowerNoPad(data []byte, suffix string) bool {
+	maxChars := (len(data)*8 + 4) / 5
+	if len(suffix) > maxChars {
+		return false
+	}
+
+	start := maxChars - len(suffix)
+	for i := 0; i < len(suffix); i++ {
+		want := suffix[i]
+		if want >= 'A' && want <= 'Z' {
+			want = want + ('a' - 'A')
+		}
+		if Alphabet[Symbol(data, start+i)] != want {
+			return false
+		}
+	}
+	return true
+}
+
+// Symbol returns the 5-bit value of the i-th character of the base32
+// encoding of data. i must be less than (len(data)*8+4)/5.
+func Symbol(data []byte, i int) byte {
+	bitOffset := i * 5
+	byteIdx := bitOffset / 8
+	bitIdx := bitOffset % 8
+
+	if bitIdx <= 3 {
+		return (data[byteIdx] >> (3 - bitIdx)) & 0x1f
+	}
+	val := (data[byteIdx] << (bitIdx - 3)) & 0x1f
+	if byteIdx+1 < len(data) {
+		val |= data[byteIdx+1] >> (11 - bitIdx)
+	}
+	return val
+}
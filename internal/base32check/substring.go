@@ -0,0 +1,58 @@
+package base32check
+
+import "fmt"
+
+// MaxSubstringLen is the longest word a Substring can hold: its 5-bit
+// characters must fit in one 64-bit window.
+const MaxSubstringLen = 12
+
+// Substring finds a word at any offset of a base32 encoding by sliding a
+// 5-bit window over the raw bytes, without encoding them.
+type Substring struct {
+	value uint64 // the word's characters, 5 bits each, first character highest
+	mask  uint64
+	n     int
+}
+
+// NewSubstring prepares word, which must be 1 to MaxSubstringLen base32
+// characters in either case.
+func NewSubstring(word string) (Substring, error) {
+	if len(word) == 0 || len(word) > MaxSubstringLen {
+		return Substring{}, fmt.Errorf("word must be 1 to %d characters", MaxSubstringLen)
+	}
+	s := Substring{n: len(word), mask: 1<<(5*len(word)) - 1}
+	for i := 0; i < len(word); i++ {
+		v := alphabetIndex(lower(word[i]))
+		if v < 0 {
+			return Substring{}, fmt.Errorf("invalid character '%c' at position %d (allowed: a-z, 2-7)", word[i], i)
+		}
+		s.value = s.value<<5 | uint64(v)
+	}
+	return s, nil
+}
+
+// In reports whether the lowercase base32 (RFC4648, no padding) encoding of
+// data contains the word.
+func (s Substring) In(data []byte) bool {
+	var bits uint64 // unconsumed input bits, low nbits valid
+	var window uint64
+	nbits, seen := 0, 0
+	for _, b := range data {
+		bits = bits<<8 | uint64(b)
+		nbits += 8
+		for nbits >= 5 {
+			nbits -= 5
+			window = (window<<5 | bits>>nbits&0x1f) & s.mask
+			if seen++; seen >= s.n && window == s.value {
+				return true
+			}
+		}
+	}
+	if nbits > 0 {
+		// The final character is padded with zero bits.
+		window = (window<<5 | bits<<(5-nbits)&0x1f) & s.mask
+		seen++
+		return seen >= s.n && window == s.value
+	}
+	return false
+}
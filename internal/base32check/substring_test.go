@@ -0,0 +1,40 @@
+package base32check
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+	"testing"
+)
+
+func TestSubstringMatchesStringsContains(t *testing.T) {
+	enc := base32.NewEncoding(Alphabet).WithPadding(base32.NoPadding)
+	// 32 bytes end on a partial character, 35 bytes on a whole one.
+	for _, size := range []int{32, 35} {
+		data := make([]byte, size)
+		for n := 0; n < 500; n++ {
+			if _, err := rand.Read(data); err != nil {
+				t.Fatal(err)
+			}
+			s := enc.EncodeToString(data)
+			words := []string{s[:1], s[len(s)-2:], s[17:20], strings.ToUpper(s[30:35]), "zz", "a", s[len(s)-12:]}
+			for _, w := range words {
+				sub, err := NewSubstring(w)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got, want := sub.In(data), strings.Contains(s, strings.ToLower(w)); got != want {
+					t.Fatalf("%q in %s: got %v, want %v", w, s, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestNewSubstringErrors(t *testing.T) {
+	for _, w := range []string{"", "ab1", strings.Repeat("a", MaxSubstringLen+1)} {
+		if _, err := NewSubstring(w); err == nil {
+			t.Errorf("NewSubstring(%q): expected an error", w)
+		}
+	}
+}
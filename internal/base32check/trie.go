@@ -0,0 +1,82 @@
+package base32check
+
+// PrefixTrie matches the base32 encoding of data against many prefixes in a
+// single pass over its characters, instead of one HasPrefixLowerNoPad call
+// per prefix. Prefixes that are patterns with wildcards or classes (see
+// ParsePattern) cannot share trie paths and are checked one by one.
+type PrefixTrie struct {
+	nodes    []trieNode
+	patterns []Pattern
+	targets  []int // index of each entry of patterns
+}
+
+type trieNode struct {
+	next   [32]int32 // child per base32 value; 0 means none, as the root is never a child
+	target int32     // index of the prefix ending here, or -1
+}
+
+// NewPrefixTrie builds a trie of prefixes, which must be valid patterns; it
+// panics otherwise. Match reports prefixes by their index in this slice; for
+// duplicate literal prefixes only the first index is reported.
+func NewPrefixTrie(prefixes []string) *PrefixTrie {
+	t := &PrefixTrie{nodes: []trieNode{{target: -1}}}
+	for i, prefix := range prefixes {
+		if !IsLiteral(prefix) {
+			t.patterns = append(t.patterns, MustParsePattern(prefix))
+			t.targets = append(t.targets, i)
+			continue
+		}
+		n := int32(0)
+		for j := 0; j < len(prefix); j++ {
+			c := lower(prefix[j])
+			v := alphabetIndex(c)
+			if v < 0 {
+				panic("base32check: invalid prefix character " + string(c))
+			}
+			if t.nodes[n].next[v] == 0 {
+				t.nodes = append(t.nodes, trieNode{target: -1})
+				t.nodes[n].next[v] = int32(len(t.nodes) - 1)
+			}
+			n = t.nodes[n].next[v]
+		}
+		if t.nodes[n].target < 0 {
+			t.nodes[n].target = int32(i)
+		}
+	}
+	return t
+}
+
+// Match appends to out the index of every prefix that the lowercase base32
+// (RFC4648, no padding) encoding of data starts with and returns the
+// extended slice. Literal prefixes come first, shortest first.
+func (t *PrefixTrie) Match(data []byte, out []int) []int {
+	if root := t.nodes[0].target; root >= 0 {
+		out = append(out, int(root))
+	}
+	maxChars := (len(data)*8 + 4) / 5
+	n := int32(0)
+	for i := 0; i < maxChars; i++ {
+		n = t.nodes[n].next[Symbol(data, i)]
+		if n == 0 {
+			break
+		}
+		if target := t.nodes[n].target; target >= 0 {
+			out = append(out, int(target))
+		}
+	}
+	for i, p := range t.patterns {
+		if p.Match(data) {
+			out = append(out, t.targets[i])
+		}
+	}
+	return out
+}
+
+func alphabetIndex(c byte) int {
+	for i := 0; i < len(Alphabet); i++ {
+		if Alphabet[i] == c {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,55 @@
+package base32check
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"slices"
+	"testing"
+)
+
+func TestPrefixTrieMatchesHasPrefix(t *testing.T) {
+	enc := base32.NewEncoding(Alphabet).WithPadding(base32.NoPadding)
+	data := make([]byte, 32)
+	for n := 0; n < 200; n++ {
+		if _, err := rand.Read(data); err != nil {
+			t.Fatal(err)
+		}
+		s := enc.EncodeToString(data)
+		prefixes := []string{s[:1], "zz", s[:3], "Q" + s[1:2], s[:3], s[:7], s[:2] + "a"}
+		trie := NewPrefixTrie(prefixes)
+
+		var want []int
+		seen := make(map[string]bool)
+		for i, p := range prefixes {
+			if HasPrefixLowerNoPad(data, p) && !seen[p] {
+				want = append(want, i)
+			}
+			seen[p] = true
+		}
+		got := trie.Match(data, nil)
+		slices.Sort(got)
+		if !slices.Equal(got, want) {
+			t.Fatalf("%s: Match = %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestPrefixTrieEmpty(t *testing.T) {
+	data := []byte{0xff, 0x00}
+	if got := NewPrefixTrie(nil).Match(data, nil); len(got) != 0 {
+		t.Errorf("empty trie matched %v", got)
+	}
+	if got := NewPrefixTrie([]string{"", "7"}).Match(data, nil); !slices.Equal(got, []int{0, 1}) {
+		t.Errorf("Match = %v, want [0 1]", got)
+	}
+}
+
+func TestPrefixTriePatterns(t *testing.T) {
+	data := []byte("foo") // "mzxw6"
+	trie := NewPrefixTrie([]string{"mz", "??x", "[a-l]", "m?y", "mzxw"})
+	got := trie.Match(data, nil)
+	slices.Sort(got)
+	if want := []int{0, 1, 4}; !slices.Equal(got, want) {
+		t.Errorf("Match = %v, want %v", got, want)
+	}
+}
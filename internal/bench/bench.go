@@ -0,0 +1,252 @@
+// Package bench measures real search throughput for each address scheme on
+// each available backend and compares reports against a saved baseline.
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/address"
+	"github.com/go-i2p/i2p-vanitygen/internal/generator"
+	"github.com/go-i2p/i2p-vanitygen/internal/gpu"
+	"github.com/go-i2p/i2p-vanitygen/internal/version"
+)
+
+// ReportVersion is bumped whenever the report layout changes incompatibly.
+const ReportVersion = 1
+
+// unreachablePrefix is long enough that a benchmark run never finds a match.
+const unreachablePrefix = "zzzzzzzzzzzz"
+
+// Measurement is the throughput of one scheme on one backend configuration.
+type Measurement struct {
+	Network     string  `json:"network"`
+	Backend     string  `json:"backend"` // "cpu" or "gpu"
+	Cores       int     `json:"cores"`
+	Device      string  `json:"device,omitempty"` // GPU device name
+	DeviceIndex int     `json:"device_index"`     // GPU index in gpu.ListDevices
+	Checked     uint64  `json:"checked"`
+	Seconds     float64 `json:"seconds"`
+	KeysPerSec  float64 `json:"keys_per_sec"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// Key identifies the configuration of a measurement across reports. GPU keys
+// carry the device index, so identical cards stay apart.
+func (m Measurement) Key() string {
+	if m.Backend == "gpu" {
+		return fmt.Sprintf("%s/gpu/%d/%s", m.Network, m.DeviceIndex, m.Device)
+	}
+	return fmt.Sprintf("%s/cpu/%d", m.Network, m.Cores)
+}
+
+// Report is the JSON document written by the bench command.
+type Report struct {
+	ReportVersion int           `json:"report_version"`
+	AppVersion    string        `json:"app_version"`
+	Time          time.Time     `json:"time"`
+	GOOS          string        `json:"goos"`
+	GOARCH        string        `json:"goarch"`
+	NumCPU        int           `json:"num_cpu"`
+	Measurements  []Measurement `json:"measurements"`
+}
+
+// Options selects what Run measures.
+type Options struct {
+	Schemes    []address.Scheme
+	CoreCounts []int
+	GPU        bool          // also measure every device from gpu.ListDevices
+	Duration   time.Duration // per measurement
+	// Progress, if set, is called before each measurement starts.
+	Progress func(m Measurement)
+}
+
+// Run measures every requested configuration in turn.
+func Run(ctx context.Context, opts Options) (*Report, error) {
+	if opts.Duration <= 0 {
+		return nil, fmt.Errorf("duration must be positive")
+	}
+	r := &Report{
+		ReportVersion: ReportVersion,
+		AppVersion:    version.Version,
+		Time:          time.Now().UTC(),
+		GOOS:          runtime.GOOS,
+		GOARCH:        runtime.GOARCH,
+		NumCPU:        runtime.NumCPU(),
+	}
+
+	var devices []gpu.Device
+	if opts.GPU && gpu.Available() {
+		devices, _ = gpu.ListDevices()
+	}
+
+	for _, scheme := range opts.Schemes {
+		for _, cores := range opts.CoreCounts {
+			m := Measurement{Network: scheme.Network().String(), Backend: "cpu", Cores: cores}
+			if opts.Progress != nil {
+				opts.Progress(m)
+			}
+			gen, err := generator.New(scheme, unreachablePrefix, cores, false, 0)
+			if err != nil {
+				return r, err
+			}
+			measure(ctx, &m, gen, opts.Duration)
+			r.Measurements = append(r.Measurements, m)
+			if ctx.Err() != nil {
+				return r, ctx.Err()
+			}
+		}
+		if !scheme.SupportsGPU() {
+			continue
+		}
+		for i, dev := range devices {
+			m := Measurement{Network: scheme.Network().String(), Backend: "gpu", Device: dev.Name, DeviceIndex: i}
+			if opts.Progress != nil {
+				opts.Progress(m)
+			}
+			gen, err := generator.New(scheme, unreachablePrefix, 0, true, i)
+			if err != nil {
+				return r, err
+			}
+			measure(ctx, &m, gen, opts.Duration)
+			r.Measurements = append(r.Measurements, m)
+			if ctx.Err() != nil {
+				return r, ctx.Err()
+			}
+		}
+	}
+	return r, nil
+}
+
+// measure runs gen for d and records the rate of the last stats tick.
+func measure(ctx context.Context, m *Measurement, gen *generator.Generator, d time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+	resultCh, statsCh := gen.Start(ctx)
+	go func() {
+		for range resultCh {
+		}
+	}()
+
+	var latest generator.Stats
+	for st := range statsCh {
+		latest = st
+	}
+	if latest.Checked == 0 || latest.Elapsed <= 0 {
+		m.Error = "no keys checked (backend failed to start)"
+		return
+	}
+	m.Checked = latest.Checked
+	m.Seconds = latest.Elapsed.Seconds()
+	m.KeysPerSec = float64(latest.Checked) / latest.Elapsed.Seconds()
+}
+
+// Load reads a report written by Save.
+func Load(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if r.ReportVersion != ReportVersion {
+		return nil, fmt.Errorf("%s: report version %d, want %d", path, r.ReportVersion, ReportVersion)
+	}
+	return &r, nil
+}
+
+// Save writes the report as indented JSON.
+func Save(r *Report, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Write(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Write encodes the report as indented JSON to w.
+func Write(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// Comparison is the change of one configuration between two reports.
+type Comparison struct {
+	Key        string
+	Baseline   float64 // keys/sec, 0 if missing from the baseline
+	Current    float64 // keys/sec, 0 if missing from the current report
+	Change     float64 // relative change, e.g. -0.15 for 15% slower
+	Regression bool
+}
+
+// Compare matches measurements by Key and flags every configuration whose
+// throughput dropped by more than threshold (e.g. 0.1 for 10%). Measurements
+// present in only one report are listed but never flagged.
+func Compare(baseline, current *Report, threshold float64) []Comparison {
+	base := make(map[string]float64)
+	for _, m := range baseline.Measurements {
+		if m.Error == "" {
+			base[m.Key()] = m.KeysPerSec
+		}
+	}
+
+	var out []Comparison
+	seen := make(map[string]bool)
+	for _, m := range current.Measurements {
+		if m.Error != "" {
+			continue
+		}
+		key := m.Key()
+		seen[key] = true
+		c := Comparison{Key: key, Baseline: base[key], Current: m.KeysPerSec}
+		if c.Baseline > 0 {
+			c.Change = (c.Current - c.Baseline) / c.Baseline
+			c.Regression = c.Change < -threshold
+		}
+		out = append(out, c)
+	}
+	for _, m := range baseline.Measurements {
+		if key := m.Key(); m.Error == "" && !seen[key] {
+			out = append(out, Comparison{Key: key, Baseline: m.KeysPerSec})
+		}
+	}
+	return out
+}
+
+// ParseCoreCounts parses a comma-separated list such as "1,4,max".
+func ParseCoreCounts(s string) ([]int, error) {
+	var counts []int
+	seen := make(map[int]bool)
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		n := runtime.NumCPU()
+		if f != "max" {
+			var err error
+			if n, err = strconv.Atoi(f); err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid core count %q", f)
+			}
+		}
+		if n > runtime.NumCPU() {
+			return nil, fmt.Errorf("core count %d exceeds the %d available", n, runtime.NumCPU())
+		}
+		if !seen[n] {
+			seen[n] = true
+			counts = append(counts, n)
+		}
+	}
+	return counts, nil
+}
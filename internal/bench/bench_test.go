@@ -0,0 +1,63 @@
+package bench
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestCompareFlagsRegressions(t *testing.T) {
+	base := &Report{Measurements: []Measurement{
+		{Network: "i2p", Backend: "cpu", Cores: 1, KeysPerSec: 1000},
+		{Network: "i2p", Backend: "gpu", Device: "X", KeysPerSec: 1e8},
+		{Network: "i2p", Backend: "gpu", Device: "X", DeviceIndex: 1, KeysPerSec: 1e8},
+		{Network: "torv3", Backend: "cpu", Cores: 1, KeysPerSec: 500},
+	}}
+	cur := &Report{Measurements: []Measurement{
+		{Network: "i2p", Backend: "cpu", Cores: 1, KeysPerSec: 950},                    // -5%: within threshold
+		{Network: "i2p", Backend: "gpu", Device: "X", KeysPerSec: 5e7},                 // -50%
+		{Network: "i2p", Backend: "gpu", Device: "X", DeviceIndex: 1, KeysPerSec: 1e8}, // same card, second slot
+		{Network: "torv3", Backend: "cpu", Cores: 8, KeysPerSec: 4000},                 // not in baseline
+	}}
+
+	got := make(map[string]Comparison)
+	for _, c := range Compare(base, cur, 0.1) {
+		got[c.Key] = c
+	}
+	if len(got) != 5 {
+		t.Fatalf("expected 5 comparisons, got %d: %+v", len(got), got)
+	}
+	if got["i2p/cpu/1"].Regression {
+		t.Error("5% slowdown should not be flagged at a 10% threshold")
+	}
+	if !got["i2p/gpu/0/X"].Regression {
+		t.Error("50% slowdown should be flagged")
+	}
+	if got["i2p/gpu/1/X"].Regression {
+		t.Error("identical cards should be compared separately")
+	}
+	if got["torv3/cpu/8"].Regression || got["torv3/cpu/8"].Baseline != 0 {
+		t.Error("new configuration should not be flagged")
+	}
+	if c := got["torv3/cpu/1"]; c.Regression || c.Current != 0 {
+		t.Error("configuration missing from the current report should be listed but not flagged")
+	}
+}
+
+func TestParseCoreCounts(t *testing.T) {
+	counts, err := ParseCoreCounts("1, max,1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{1}
+	if runtime.NumCPU() > 1 {
+		want = append(want, runtime.NumCPU())
+	}
+	if len(counts) != len(want) {
+		t.Fatalf("got %v, want %v", counts, want)
+	}
+	for _, bad := range []string{"0", "x", "4x", "-1", ""} {
+		if _, err := ParseCoreCounts(bad); err == nil {
+			t.Errorf("ParseCoreCounts(%q) should fail", bad)
+		}
+	}
+}
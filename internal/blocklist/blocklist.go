@@ -0,0 +1,176 @@
+// Package blocklist keeps unwanted words out of generated addresses. A
+// Blocklist is checked against the whole address of every match, so the
+// search goes on past an address that reads as an insult, however well it
+// matches the prefix.
+//
+// The built-in list (words.txt) holds common offensive words; users can add
+// their own from a file in the same format: one word per line, base32
+// characters only, with blank lines and lines starting with '#' ignored.
+package blocklist
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/address"
+	"github.com/go-i2p/i2p-vanitygen/internal/base32check"
+	"github.com/go-i2p/i2p-vanitygen/internal/dfa"
+)
+
+//go:embed words.txt
+var builtin string
+
+// Blocklist is a set of words no accepted address may contain, compiled
+// into one automaton so an address is checked in a single pass whatever
+// the number of words. It is immutable and safe for concurrent use.
+type Blocklist struct {
+	words []string
+	d     *dfa.DFA
+}
+
+// New compiles words, each 1 to base32check.MaxSubstringLen base32
+// characters in either case.
+func New(words []string) (*Blocklist, error) {
+	if len(words) == 0 {
+		return nil, fmt.Errorf("blocklist is empty")
+	}
+	b := &Blocklist{}
+	for _, w := range words {
+		if _, err := base32check.NewSubstring(w); err != nil {
+			return nil, fmt.Errorf("blocked word %q: %w", w, err)
+		}
+		if w = strings.ToLower(w); !slices.Contains(b.words, w) {
+			b.words = append(b.words, w)
+		}
+	}
+	// Words are plain base32 literals, so the alternation needs no quoting
+	// and its automaton stays within a state per character.
+	d, err := dfa.Compile(strings.Join(b.words, "|"))
+	if err != nil {
+		return nil, err
+	}
+	b.d = d
+	return b, nil
+}
+
+var defaultList = sync.OnceValue(func() *Blocklist {
+	b, err := New(parse(builtin))
+	if err != nil {
+		panic("blocklist: built-in list: " + err.Error())
+	}
+	return b
+})
+
+// Default returns the built-in list.
+func Default() *Blocklist { return defaultList() }
+
+// Load reads the words of a blocklist file.
+func Load(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var words []string
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		w := strings.TrimSpace(sc.Text())
+		if w == "" || w[0] == '#' {
+			continue
+		}
+		if _, err := base32check.NewSubstring(w); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		words = append(words, w)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return words, nil
+}
+
+// With returns b extended by words.
+func (b *Blocklist) With(words []string) (*Blocklist, error) {
+	return New(append(slices.Clone(b.words), words...))
+}
+
+// Words returns the blocked words, lowercased.
+func (b *Blocklist) Words() []string { return slices.Clone(b.words) }
+
+// Match reports whether the lowercase base32 encoding of data contains a
+// blocked word, which makes a Blocklist an address.Matcher for rejecting
+// candidates.
+func (b *Blocklist) Match(data []byte) bool { return b.d.Match(data) }
+
+// Find returns the first blocked word s contains, in either case, or "".
+func (b *Blocklist) Find(s string) string {
+	s = strings.ToLower(s)
+	for _, w := range b.words {
+		if strings.Contains(s, w) {
+			return w
+		}
+	}
+	return ""
+}
+
+// PassProbability returns the chance that an address of s matching prefix
+// (a pattern, see base32check.ParsePattern) and suffix contains no blocked
+// word. The search needs 1/PassProbability times the attempts it would
+// without the blocklist. An invalid prefix gives 0.
+func (b *Blocklist) PassProbability(s address.Scheme, prefix, suffix string) float64 {
+	p, err := base32check.ParsePattern(prefix)
+	if err != nil {
+		return 0
+	}
+	suffix = strings.ToLower(suffix)
+	start := s.MaxPrefixLen() - len(suffix)
+	symbols := func(pos int) uint32 {
+		set := s.Symbols(pos)
+		if pos < len(p) {
+			set &= p[pos]
+		}
+		if pos >= start {
+			if v := strings.IndexByte(base32check.Alphabet, suffix[pos-start]); v >= 0 {
+				set &= 1 << v
+			} else {
+				set = 0
+			}
+		}
+		return set
+	}
+	for pos := 0; pos < s.MaxPrefixLen(); pos++ {
+		if symbols(pos) == 0 {
+			return 0 // nothing matches, blocked or not
+		}
+	}
+	return 1 - b.d.Probability(symbols, s.MaxPrefixLen())
+}
+
+// AdjustAttempts returns the attempts estimate of a search for prefix and
+// suffix once matches containing a blocked word are skipped.
+func (b *Blocklist) AdjustAttempts(s address.Scheme, prefix, suffix string, attempts float64) float64 {
+	p := b.PassProbability(s, prefix, suffix)
+	if p == 0 {
+		return math.Inf(1)
+	}
+	return attempts / p
+}
+
+// parse returns the words of a list in the words.txt format.
+func parse(list string) []string {
+	var words []string
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && line[0] != '#' {
+			words = append(words, line)
+		}
+	}
+	return words
+}
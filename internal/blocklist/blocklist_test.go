@@ -0,0 +1,121 @@
+package blocklist
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/address"
+	"github.com/go-i2p/i2p-vanitygen/internal/base32check"
+)
+
+func TestMatchAgreesWithFind(t *testing.T) {
+	b, err := New([]string{"ab", "bad", "zz"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoding := base32.NewEncoding(base32check.Alphabet).WithPadding(base32.NoPadding)
+	data := make([]byte, 32)
+	blocked := 0
+	for n := 0; n < 2000; n++ {
+		if _, err := rand.Read(data); err != nil {
+			t.Fatal(err)
+		}
+		s := encoding.EncodeToString(data)
+		want := b.Find(s) != ""
+		if got := b.Match(data); got != want {
+			t.Fatalf("Match on %s = %v, want %v", s, got, want)
+		}
+		if want {
+			blocked++
+		}
+	}
+	if blocked == 0 {
+		t.Error("no random address contained a blocked word")
+	}
+}
+
+func TestDefault(t *testing.T) {
+	b := Default()
+	if got := b.Find("helloFUCKworld"); got != "fuck" {
+		t.Errorf("Find = %q, want \"fuck\"", got)
+	}
+	if got := b.Find("hello"); got != "" {
+		t.Errorf("Find(\"hello\") = %q", got)
+	}
+	for _, w := range b.Words() {
+		if _, err := base32check.NewSubstring(w); err != nil {
+			t.Errorf("built-in word %q: %v", w, err)
+		}
+	}
+}
+
+func TestPassProbability(t *testing.T) {
+	b, err := New([]string{"a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := address.I2PScheme{}
+	// 51 free characters out of 32 (the last one out of a and q).
+	want := math.Pow(31.0/32, 51) / 2
+	if got := b.PassProbability(s, "", ""); math.Abs(got-want) > 1e-12 {
+		t.Errorf("PassProbability = %v, want %v", got, want)
+	}
+	// The prefix is fixed, so only the other 49 free characters count.
+	want = math.Pow(31.0/32, 49) / 2
+	if got := b.PassProbability(s, "bc", ""); math.Abs(got-want) > 1e-12 {
+		t.Errorf("PassProbability(bc) = %v, want %v", got, want)
+	}
+	if got := b.PassProbability(s, "ba", ""); got != 0 {
+		t.Errorf("PassProbability(ba) = %v, want 0", got)
+	}
+	// Half of the prefix matches are blocked, and the suffix fixes the last
+	// character.
+	if got := b.PassProbability(s, "[ab]", "q"); math.Abs(got-math.Pow(31.0/32, 50)/2) > 1e-12 {
+		t.Errorf("PassProbability([ab], q) = %v", got)
+	}
+	if got := b.AdjustAttempts(s, "ba", "", 100); !math.IsInf(got, 1) {
+		t.Errorf("AdjustAttempts(ba) = %v, want +Inf", got)
+	}
+
+	d := Default()
+	if p := d.PassProbability(s, "shop", ""); p < 0.9 || p >= 1 {
+		t.Errorf("built-in list passes %v of shop addresses", p)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "words.txt")
+	if err := os.WriteFile(path, []byte("# mine\nFoo\n\n  bar \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	words, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(words, ",") != "Foo,bar" {
+		t.Errorf("Load = %q", words)
+	}
+	b, err := Default().With(words)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Find("xfoo") != "foo" || b.Find("xfuck") != "fuck" {
+		t.Errorf("merged list is missing words: %v", b.Words())
+	}
+
+	if err := os.WriteFile(path, []byte("ok\nb0rk\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Errorf("invalid word: error %v, want one naming line 2", err)
+	}
+	if _, err := New(nil); err == nil {
+		t.Error("empty list accepted")
+	}
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/format"
+	"github.com/go-i2p/i2p-vanitygen/internal/generator"
+	"github.com/go-i2p/i2p-vanitygen/internal/jobs"
+)
+
+func runBatch(args []string) int {
+	fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: i2p-vanitygen batch [flags] JOBFILE")
+		fmt.Fprintln(stderr, "\nRuns every job in JOBFILE in order; see internal/jobs for the file format.")
+		fs.PrintDefaults()
+	}
+	resultsPath := fs.String("results", "", "where to record per-job results (default JOBFILE.results.json)")
+	interval := fs.Duration("interval", 10*time.Second, "progress report interval (0 disables)")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	jobFile := fs.Arg(0)
+	if *resultsPath == "" {
+		*resultsPath = strings.TrimSuffix(jobFile, ".json") + ".results.json"
+	}
+
+	list, err := jobs.Load(jobFile)
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var (
+		results   []jobs.Result
+		lastPrint time.Duration
+	)
+	runner := &jobs.Runner{
+		OnStart: func(job jobs.Job) {
+			lastPrint = 0
+			fmt.Fprintf(stderr, "[%s] searching %s for an address %s\n", job.Name, job.Network, describeJob(job))
+		},
+		OnStats: func(job jobs.Job, st generator.Stats) {
+			if *interval <= 0 || st.Elapsed-lastPrint < *interval {
+				return
+			}
+			lastPrint = st.Elapsed
+			fmt.Fprintf(stderr, "[%s] checked %s  speed %s keys/sec  elapsed %s\n",
+				job.Name, format.Uint(st.Checked), format.Number(st.KeysPerSec), format.Duration(st.Elapsed))
+		},
+		OnResult: func(r jobs.Result) {
+			results = append(results, r)
+			line := fmt.Sprintf("[%s] %s", r.Name, r.Status)
+			if r.Address != "" {
+				line += " " + r.Address
+			}
+			if r.Error != "" {
+				line += ": " + r.Error
+			}
+			fmt.Fprintln(stderr, line)
+			// Record progress after every job so a crash loses nothing.
+			if err := jobs.SaveResults(*resultsPath, results); err != nil {
+				fmt.Fprintln(stderr, "error: writing results:", err)
+			}
+		},
+	}
+	runner.Run(ctx, list)
+
+	counts := make(map[jobs.Status]int)
+	for _, r := range results {
+		counts[r.Status]++
+	}
+	fmt.Fprintf(stderr, "%d found, %d skipped, %d not found, %d failed, %d interrupted; results in %s\n",
+		counts[jobs.StatusFound], counts[jobs.StatusSkipped], counts[jobs.StatusNotFound],
+		counts[jobs.StatusFailed], counts[jobs.StatusInterrupted], *resultsPath)
+
+	switch {
+	case counts[jobs.StatusInterrupted] > 0:
+		return exitInterrupted
+	case counts[jobs.StatusFailed] > 0:
+		return exitError
+	case counts[jobs.StatusNotFound] > 0:
+		return exitNotFound
+	}
+	return exitOK
+}
@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/address"
+	"github.com/go-i2p/i2p-vanitygen/internal/bench"
+	"github.com/go-i2p/i2p-vanitygen/internal/format"
+)
+
+func runBench(args []string) int {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: i2p-vanitygen bench [flags]")
+		fmt.Fprintln(stderr, "       i2p-vanitygen bench -baseline OLD.json -compare NEW.json")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+	network := fs.String("network", "all", "network to measure: i2p, torv3 or all")
+	cores := fs.String("cores", "1,max", "comma-separated CPU core counts to measure")
+	useGPU := fs.Bool("gpu", true, "also measure each GPU device")
+	duration := fs.Duration("duration", 3*time.Second, "how long to run each measurement")
+	out := fs.String("out", "", "write the JSON report to this file (default stdout)")
+	baseline := fs.String("baseline", "", "compare against this saved report")
+	compare := fs.String("compare", "", "compare this saved report against -baseline instead of running")
+	threshold := fs.Float64("threshold", 0.10, "relative slowdown that counts as a regression")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	var base *bench.Report
+	if *baseline != "" {
+		var err error
+		if base, err = bench.Load(*baseline); err != nil {
+			fmt.Fprintln(stderr, "error:", err)
+			return exitUsage
+		}
+	}
+
+	if *compare != "" {
+		if base == nil {
+			fmt.Fprintln(stderr, "error: -compare requires -baseline")
+			return exitUsage
+		}
+		current, err := bench.Load(*compare)
+		if err != nil {
+			fmt.Fprintln(stderr, "error:", err)
+			return exitUsage
+		}
+		return printComparison(bench.Compare(base, current, *threshold), *threshold)
+	}
+
+	opts := bench.Options{GPU: *useGPU, Duration: *duration}
+	if *network == "all" {
+		opts.Schemes = []address.Scheme{address.I2PScheme{}, address.TorV3Scheme{}}
+	} else {
+		scheme, err := address.LookupScheme(*network)
+		if err != nil {
+			fmt.Fprintln(stderr, "error:", err)
+			return exitUsage
+		}
+		opts.Schemes = []address.Scheme{scheme}
+	}
+	counts, err := bench.ParseCoreCounts(*cores)
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return exitUsage
+	}
+	opts.CoreCounts = counts
+	opts.Progress = func(m bench.Measurement) {
+		fmt.Fprintf(stderr, "measuring %s ...\n", m.Key())
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	report, err := bench.Run(ctx, opts)
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		if ctx.Err() != nil {
+			return exitInterrupted
+		}
+		return exitError
+	}
+
+	for _, m := range report.Measurements {
+		if m.Error != "" {
+			fmt.Fprintf(stderr, "  %-24s %s\n", m.Key(), m.Error)
+			continue
+		}
+		fmt.Fprintf(stderr, "  %-24s %s keys/sec\n", m.Key(), format.Number(m.KeysPerSec))
+	}
+
+	if *out == "" {
+		bench.Write(stdout, report)
+	} else if err := bench.Save(report, *out); err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return exitError
+	}
+
+	if base != nil {
+		return printComparison(bench.Compare(base, report, *threshold), *threshold)
+	}
+	return exitOK
+}
+
+// printComparison writes a table to stderr and returns exitRegression if any
+// configuration got slower than the threshold allows.
+func printComparison(cmp []bench.Comparison, threshold float64) int {
+	code := exitOK
+	fmt.Fprintf(stderr, "%-24s %14s %14s %8s\n", "CONFIGURATION", "BASELINE", "CURRENT", "CHANGE")
+	for _, c := range cmp {
+		change := "-"
+		if c.Baseline > 0 && c.Current > 0 {
+			change = fmt.Sprintf("%+.1f%%", c.Change*100)
+		}
+		mark := ""
+		if c.Regression {
+			mark = "  REGRESSION"
+			code = exitRegression
+		}
+		fmt.Fprintf(stderr, "%-24s %14s %14s %8s%s\n", c.Key, rate(c.Baseline), rate(c.Current), change, mark)
+	}
+	if code == exitRegression {
+		fmt.Fprintf(stderr, "throughput dropped by more than %.0f%%\n", threshold*100)
+	}
+	return code
+}
+
+func rate(kps float64) string {
+	if kps == 0 {
+		return "-"
+	}
+	return format.Number(kps) + "/s"
+}
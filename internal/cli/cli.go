@@ -0,0 +1,97 @@
+// Package cli implements the headless subcommands that run searches and
+// manage key material without opening the Gio window.
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/address"
+)
+
+// Exit codes returned by Run.
+const (
+	exitOK          = 0
+	exitError       = 1   // runtime failure (I/O, GPU, ...)
+	exitUsage       = 2   // bad flags or arguments
+	exitNotFound    = 3   // search ended without a match
+	exitRegression  = 4   // bench found a throughput regression
+	exitInterrupted = 130 // stopped by SIGINT/SIGTERM
+)
+
+// Output streams, replaceable in tests.
+var (
+	stdout io.Writer = os.Stdout
+	stderr io.Writer = os.Stderr
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+func commands() []command {
+	return []command{
+		{"search", "search for a vanity address and save its keys", runSearch},
+		{"inspect", "check saved key files and print their addresses", runInspect},
+		{"bench", "measure keys/sec per scheme and backend", runBench},
+		{"batch", "run the searches listed in a job file", runBatch},
+		{"keygen", "generate random (non-vanity) identities in bulk", runKeygen},
+		{"daemon", "serve a local HTTP API that queues searches", runDaemon},
+		{"sign", "sign a message with a saved identity", runSign},
+		{"verify", "check a signature made by sign", runVerify},
+		{"convert", "re-encode saved keys for other I2P and Tor tools", runConvert},
+		{"selftest", "check every scheme and backend against known-answer vectors", runSelftest},
+	}
+}
+
+// Run executes the subcommand named by args[0] and returns the process exit code.
+func Run(args []string) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitUsage
+	}
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		usage(stdout)
+		return exitOK
+	}
+	for _, c := range commands() {
+		if c.name == args[0] {
+			return c.run(args[1:])
+		}
+	}
+	fmt.Fprintf(stderr, "unknown command %q\n\n", args[0])
+	usage(stderr)
+	return exitUsage
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: i2p-vanitygen <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run without arguments to open the graphical interface.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands() {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'i2p-vanitygen <command> -h' for the flags of a command.")
+}
+
+// withCrypto applies the -crypto flag of keygen and search to scheme.
+func withCrypto(scheme address.Scheme, crypto string) (address.Scheme, error) {
+	switch crypto {
+	case "elgamal":
+		return scheme, nil
+	case "x25519":
+		if _, ok := scheme.(address.I2PScheme); !ok {
+			return nil, errors.New("-crypto applies only to -network i2p")
+		}
+		return address.I2PScheme{X25519: true}, nil
+	}
+	return nil, fmt.Errorf("unknown -crypto %q (allowed: elgamal, x25519)", crypto)
+}
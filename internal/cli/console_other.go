@@ -0,0 +1,7 @@
+//go:build !windows
+
+package cli
+
+// AttachConsole is a no-op on non-Windows platforms, where the binary always
+// writes to the terminal that started it.
+func AttachConsole() {}
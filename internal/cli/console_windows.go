@@ -0,0 +1,44 @@
+//go:build windows
+
+package cli
+
+import (
+	"os"
+	"syscall"
+)
+
+var pAttachConsole = syscall.NewLazyDLL("kernel32.dll").NewProc("AttachConsole")
+
+// attachParentProcess is ATTACH_PARENT_PROCESS, (DWORD)-1.
+const attachParentProcess = ^uint32(0)
+
+// AttachConsole connects the output of a GUI-subsystem build (the release is
+// linked with -H=windowsgui) to the console it was started from, so headless
+// commands print to the terminal. Streams that are already redirected to a
+// file or pipe are left alone. Call it before Run.
+func AttachConsole() {
+	if r, _, _ := pAttachConsole.Call(uintptr(attachParentProcess)); r == 0 {
+		return // started from Explorer, or the parent has no console
+	}
+	if f := consoleOutput(os.Stdout); f != nil {
+		os.Stdout, stdout = f, f
+	}
+	if f := consoleOutput(os.Stderr); f != nil {
+		os.Stderr, stderr = f, f
+	}
+}
+
+// consoleOutput opens the attached console for writing if f has no usable
+// handle, and returns nil if f already goes somewhere.
+func consoleOutput(f *os.File) *os.File {
+	if f != nil {
+		if t, err := syscall.GetFileType(syscall.Handle(f.Fd())); err == nil && t != syscall.FILE_TYPE_UNKNOWN {
+			return nil
+		}
+	}
+	con, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0)
+	if err != nil {
+		return nil
+	}
+	return con
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/address"
+	"github.com/go-i2p/i2p-vanitygen/internal/convert"
+)
+
+func runConvert(args []string) int {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: i2p-vanitygen convert -to FORMAT -out PATH SOURCE")
+		fmt.Fprintln(stderr, "\nSOURCE is an I2P .dat file or base64 private key, a Tor hidden service directory,")
+		fmt.Fprintln(stderr, "an hs_ed25519_secret_key file or an ADD_ONION key blob.")
+		fmt.Fprintln(stderr, "\nFormats:")
+		fmt.Fprintln(stderr, "  i2p:   "+joinFormats(address.NetworkI2P))
+		fmt.Fprintln(stderr, "  torv3: "+joinFormats(address.NetworkTorV3))
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+	to := fs.String("to", "", "output format")
+	outPath := fs.String("out", "", "file (or directory for tor-dir) to write")
+	force := fs.Bool("force", false, "overwrite an existing output")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if *to == "" || *outPath == "" || fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	id, err := convert.Load(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return exitError
+	}
+	if err := convert.Write(id, convert.Format(*to), *outPath, *force); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			err = fmt.Errorf("%w (use -force to overwrite)", err)
+		}
+		fmt.Fprintln(stderr, "error:", err)
+		return exitError
+	}
+	fmt.Fprintf(stderr, "wrote %s as %s to %s\n", id.Address(), *to, *outPath)
+	return exitOK
+}
+
+func joinFormats(network address.Network) string {
+	var names []string
+	for _, f := range convert.Formats(network) {
+		names = append(names, string(f))
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/daemon"
+	"github.com/go-i2p/i2p-vanitygen/internal/jobs"
+)
+
+// tokenEnv names the environment variable read when -token is not given.
+const tokenEnv = "I2P_VANITYGEN_TOKEN"
+
+// defaultListen is the daemon's default address. The port stays clear of
+// the I2P router's 7654-7670 range (console, SAM, I2CP and friends) and of
+// Tor's 9050/9051.
+const defaultListen = "127.0.0.1:8397"
+
+func runDaemon(args []string) int {
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: i2p-vanitygen daemon [flags]")
+		fmt.Fprintln(stderr, "\nServes a local HTTP/JSON API for queued searches; see internal/daemon for the endpoints.")
+		fs.PrintDefaults()
+	}
+	listen := fs.String("listen", defaultListen, "loopback host:port, or unix:/path/to/socket")
+	token := fs.String("token", "", "bearer token clients must send (default $"+tokenEnv+", or a random one)")
+	outDir := fs.String("out", "vanity-keys", "directory the found keys are written to")
+	concurrency := fs.Int("concurrency", 1, "number of searches run at once")
+	keep := fs.Int("keep", 1024, "number of finished jobs the API remembers")
+	cores := fs.Int("cores", runtime.NumCPU(), "default CPU cores per search")
+	useGPU := fs.Bool("gpu", false, "use the GPU by default")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	if *concurrency < 1 {
+		fmt.Fprintln(stderr, "error: -concurrency must be at least 1")
+		return exitUsage
+	}
+	if *keep < 1 {
+		fmt.Fprintln(stderr, "error: -keep must be at least 1")
+		return exitUsage
+	}
+	if *cores < 0 || *cores > runtime.NumCPU() {
+		fmt.Fprintf(stderr, "error: -cores must be between 0 and %d\n", runtime.NumCPU())
+		return exitUsage
+	}
+
+	ln, err := listenLocal(*listen)
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return exitUsage
+	}
+
+	if *token == "" {
+		*token = os.Getenv(tokenEnv)
+	}
+	if *token == "" {
+		var b [16]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			ln.Close()
+			fmt.Fprintln(stderr, "error:", err)
+			return exitError
+		}
+		*token = hex.EncodeToString(b[:])
+		fmt.Fprintln(stderr, "generated bearer token:", *token)
+	}
+
+	srv, err := daemon.New(daemon.Config{
+		Token:        *token,
+		OutDir:       *outDir,
+		Concurrency:  *concurrency,
+		KeepFinished: *keep,
+		Defaults:     jobs.Job{Cores: cores, GPU: useGPU},
+	})
+	if err != nil {
+		ln.Close()
+		fmt.Fprintln(stderr, "error:", err)
+		return exitError
+	}
+	defer srv.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	httpSrv := &http.Server{Handler: srv, ReadHeaderTimeout: 10 * time.Second}
+	errCh := make(chan error, 1)
+	go func() { errCh <- httpSrv.Serve(ln) }()
+	fmt.Fprintf(stderr, "listening on %s\n", *listen)
+
+	select {
+	case err := <-errCh:
+		fmt.Fprintln(stderr, "error:", err)
+		return exitError
+	case <-ctx.Done():
+	}
+
+	fmt.Fprintln(stderr, "shutting down")
+	// Cancel the searches first so open event streams see their final event.
+	srv.Close()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	httpSrv.Shutdown(shutdownCtx)
+	return exitOK
+}
+
+// listenLocal listens on a Unix socket ("unix:/path") or a loopback TCP
+// address. Other hosts are refused because the API hands out private keys.
+func listenLocal(addr string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			os.Remove(path) // stale socket from a previous run
+		}
+		return listenUnix(path)
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -listen address: %w", err)
+	}
+	if host != "localhost" {
+		ip := net.ParseIP(host)
+		if ip == nil || !ip.IsLoopback() {
+			return nil, fmt.Errorf("refusing to listen on non-loopback host %q", host)
+		}
+	}
+	return net.Listen("tcp", addr)
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/keyfile"
+)
+
+func runInspect(args []string) int {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: i2p-vanitygen inspect [flags] PATH...")
+		fmt.Fprintln(stderr, "\nPATH is an I2P .dat key file, a Tor hidden service directory, or a directory containing either.")
+		fs.PrintDefaults()
+	}
+	asJSON := fs.Bool("json", false, "print one JSON object per key set instead of text")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	code := exitOK
+	enc := json.NewEncoder(stdout)
+	for _, path := range fs.Args() {
+		infos, err := keyfile.Inspect(path)
+		if err != nil {
+			fmt.Fprintln(stderr, "error:", err)
+			code = exitError
+			continue
+		}
+		for _, info := range infos {
+			if !info.OK() {
+				code = exitError
+			}
+			if *asJSON {
+				enc.Encode(info)
+				continue
+			}
+			printInfo(info)
+		}
+	}
+	return code
+}
+
+func printInfo(info *keyfile.Info) {
+	fmt.Fprintln(stdout, info.Path)
+	fmt.Fprintf(stdout, "  network:     %s\n", info.Network)
+	if info.Address != "" {
+		fmt.Fprintf(stdout, "  address:     %s\n", info.Address)
+	}
+	if info.Destination != "" {
+		fmt.Fprintf(stdout, "  destination: %s\n", info.Destination)
+	}
+	if info.OK() {
+		fmt.Fprintln(stdout, "  status:      OK")
+	} else {
+		fmt.Fprintf(stdout, "  status:      %d problem(s)\n", len(info.Problems))
+		for _, p := range info.Problems {
+			fmt.Fprintf(stdout, "    - %s\n", p)
+		}
+	}
+	fmt.Fprintln(stdout)
+}
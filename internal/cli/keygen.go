@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/address"
+	"github.com/go-i2p/i2p-vanitygen/internal/keygen"
+)
+
+func runKeygen(args []string) int {
+	fs := flag.NewFlagSet("keygen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: i2p-vanitygen keygen [flags]")
+		fmt.Fprintln(stderr, "\nWrites COUNT random (non-vanity) identities and an "+keygen.IndexFile+" mapping address to path.")
+		fs.PrintDefaults()
+	}
+	network := fs.String("network", "i2p", "address network: i2p or torv3")
+	crypto := fs.String("crypto", "elgamal", "I2P encryption key type: elgamal or x25519")
+	count := fs.Int("count", 1, "number of identities to generate")
+	cores := fs.Int("cores", runtime.NumCPU(), "number of parallel workers")
+	outDir := fs.String("out", "keys", "directory the keys and index are written to")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	scheme, err := address.LookupScheme(*network)
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return exitUsage
+	}
+	if scheme, err = withCrypto(scheme, *crypto); err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return exitUsage
+	}
+	if *count < 1 {
+		fmt.Fprintln(stderr, "error: -count must be at least 1")
+		return exitUsage
+	}
+	if *cores < 1 || *cores > runtime.NumCPU() {
+		fmt.Fprintf(stderr, "error: -cores must be between 1 and %d\n", runtime.NumCPU())
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	start := time.Now()
+	entries, genErr := keygen.Generate(ctx, scheme, *outDir, *count, *cores, nil)
+
+	// Index whatever was saved, even after a failure or interrupt.
+	indexPath := filepath.Join(*outDir, keygen.IndexFile)
+	if len(entries) > 0 {
+		idx := &keygen.Index{Network: scheme.Network().String(), Created: time.Now().UTC(), Keys: entries}
+		if err := keygen.WriteIndex(indexPath, idx); err != nil {
+			fmt.Fprintln(stderr, "error: writing index:", err)
+			return exitError
+		}
+	}
+
+	switch {
+	case errors.Is(genErr, context.Canceled):
+		fmt.Fprintf(stderr, "interrupted after %d of %d identities\n", len(entries), *count)
+		return exitInterrupted
+	case genErr != nil:
+		fmt.Fprintln(stderr, "error:", genErr)
+		return exitError
+	}
+	fmt.Fprintf(stderr, "generated %d %s identities in %s\n", len(entries), scheme.Network(), time.Since(start).Round(time.Millisecond))
+	fmt.Fprintln(stdout, indexPath)
+	return exitOK
+}
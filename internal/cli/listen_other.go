@@ -0,0 +1,22 @@
+//go:build !unix
+
+package cli
+
+import (
+	"net"
+	"os"
+)
+
+// listenUnix creates the socket and restricts it to the current user. There
+// is no umask on these platforms, so the mode is set right after creation.
+func listenUnix(path string) (net.Listener, error) {
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
@@ -0,0 +1,16 @@
+//go:build unix
+
+package cli
+
+import (
+	"net"
+	"syscall"
+)
+
+// listenUnix creates the socket under a 0077 umask, so it is private to the
+// current user from the moment it exists rather than after a later chmod.
+func listenUnix(path string) (net.Listener, error) {
+	old := syscall.Umask(0077)
+	defer syscall.Umask(old)
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/events"
+	"github.com/go-i2p/i2p-vanitygen/internal/format"
+	"github.com/go-i2p/i2p-vanitygen/internal/generator"
+	"github.com/go-i2p/i2p-vanitygen/internal/jobs"
+)
+
+// searchReporter presents the progress and outcome of a search, either as
+// human-readable text or as an NDJSON event stream.
+type searchReporter interface {
+	start(ev *events.Start)
+	stats(st generator.Stats)
+	result(ev *events.Result)
+	fail(code string, err error)
+}
+
+func newReporter(name string, interval time.Duration) (searchReporter, error) {
+	switch name {
+	case "text":
+		return &textReporter{interval: interval}, nil
+	case "ndjson":
+		return &ndjsonReporter{w: events.NewWriter(stdout)}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q (allowed: text, ndjson)", name)
+	}
+}
+
+// textReporter writes progress to stderr at most once per interval and the
+// found address to stdout.
+type textReporter struct {
+	interval  time.Duration
+	attempts  float64
+	lastPrint time.Duration
+}
+
+func (r *textReporter) start(ev *events.Start) {
+	r.attempts = ev.EstimatedAttempts
+	if ev.Regex != "" {
+		fmt.Fprintf(stderr, "Searching for a %s address matching /%s/", ev.Network, ev.Regex)
+	} else if ev.Contains != "" {
+		fmt.Fprintf(stderr, "Searching for a %s address containing %q", ev.Network, ev.Contains)
+	} else if ev.Expr != "" {
+		fmt.Fprintf(stderr, "Searching for a %s address where %s", ev.Network, ev.Expr)
+	} else if len(ev.Prefixes) > 1 {
+		fmt.Fprintf(stderr, "Searching for %d %s addresses, one %s", len(ev.Prefixes), ev.Network, describePattern(strings.Join(ev.Prefixes, "|"), ev.Suffix))
+	} else {
+		fmt.Fprintf(stderr, "Searching for a %s address %s", ev.Network, describePattern(ev.Prefix, ev.Suffix))
+	}
+	fmt.Fprintf(stderr, " on %d core(s)", ev.Cores)
+	if ev.GPU {
+		fmt.Fprint(stderr, " + GPU")
+	}
+	fmt.Fprintln(stderr)
+}
+
+func (r *textReporter) stats(st generator.Stats) {
+	if r.interval <= 0 || st.Elapsed-r.lastPrint < r.interval {
+		return
+	}
+	r.lastPrint = st.Elapsed
+	fmt.Fprintf(stderr, "checked %s  speed %s keys/sec  elapsed %s  eta %s",
+		format.Uint(st.Checked), format.Number(st.KeysPerSec),
+		format.Duration(st.Elapsed), format.ETA(r.attempts, st.Checked, st.KeysPerSec))
+	if st.Best != nil {
+		fmt.Fprintf(stderr, "  best %s (%d chars)", st.Best.Address, st.BestLen)
+	}
+	fmt.Fprintln(stderr)
+}
+
+func (r *textReporter) result(ev *events.Result) {
+	if ev.Target != "" {
+		fmt.Fprintf(stderr, "Found %q ", ev.Target)
+	} else {
+		fmt.Fprint(stderr, "Found ")
+	}
+	fmt.Fprintf(stderr, "in %s (%s attempts)",
+		format.Duration(time.Duration(ev.DurationSec*float64(time.Second))), format.Uint(ev.Attempts))
+	if ev.Score != nil {
+		fmt.Fprintf(stderr, ", score %.2f", *ev.Score)
+	}
+	fmt.Fprintln(stderr)
+	fmt.Fprintln(stdout, ev.Address)
+	for _, p := range ev.SavedPaths {
+		fmt.Fprintln(stderr, "Keys saved to", p)
+	}
+}
+
+func (r *textReporter) fail(code string, err error) {
+	fmt.Fprintln(stderr, "error:", err)
+}
+
+// ndjsonReporter emits one events.Event per line on stdout.
+type ndjsonReporter struct {
+	w        *events.Writer
+	attempts float64
+}
+
+func (r *ndjsonReporter) start(ev *events.Start) {
+	r.attempts = ev.EstimatedAttempts
+	r.w.Emit(ev)
+}
+
+func (r *ndjsonReporter) stats(st generator.Stats) {
+	r.w.Emit(events.NewStats(st, r.attempts))
+}
+
+func (r *ndjsonReporter) result(ev *events.Result) {
+	r.w.Emit(ev)
+}
+
+func (r *ndjsonReporter) fail(code string, err error) {
+	r.w.Emit(&events.Error{Code: code, Message: err.Error()})
+}
+
+// describeJob phrases the search of a batch job for progress messages.
+func describeJob(job jobs.Job) string {
+	if job.Regex != "" {
+		return fmt.Sprintf("matching /%s/", job.Regex)
+	}
+	if job.Contains != "" {
+		return fmt.Sprintf("containing %q", job.Contains)
+	}
+	if job.Expr != "" {
+		return "where " + job.Expr
+	}
+	return describePattern(job.Prefix, job.Suffix)
+}
+
+// describePattern phrases a prefix/suffix pair for progress messages.
+func describePattern(prefix, suffix string) string {
+	switch {
+	case suffix == "":
+		return fmt.Sprintf("starting with %q", prefix)
+	case prefix == "":
+		return fmt.Sprintf("ending with %q", suffix)
+	}
+	return fmt.Sprintf("starting with %q and ending with %q", prefix, suffix)
+}
@@ -0,0 +1,508 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/address"
+	"github.com/go-i2p/i2p-vanitygen/internal/blocklist"
+	"github.com/go-i2p/i2p-vanitygen/internal/events"
+	"github.com/go-i2p/i2p-vanitygen/internal/expr"
+	"github.com/go-i2p/i2p-vanitygen/internal/format"
+	"github.com/go-i2p/i2p-vanitygen/internal/generator"
+	"github.com/go-i2p/i2p-vanitygen/internal/gpu"
+	"github.com/go-i2p/i2p-vanitygen/internal/lookalike"
+	"github.com/go-i2p/i2p-vanitygen/internal/score"
+)
+
+func runSearch(args []string) int {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	network := fs.String("network", "i2p", "address network: i2p or torv3")
+	crypto := fs.String("crypto", "elgamal", "I2P encryption key type: elgamal or x25519")
+	prefix := fs.String("prefix", "", "base32 prefix to search for (a-z, 2-7), or a comma-separated list searched for in one pass")
+	suffix := fs.String("suffix", "", "base32 characters the address must end with")
+	regex := fs.String("regex", "", "regular expression the address must match, instead of -prefix and -suffix")
+	like := fs.String("lookalike", "", "word to search for in every lookalike spelling at once, e.g. he11o, instead of -prefix")
+	contains := fs.String("contains", "", "base32 word the address must contain anywhere, instead of -prefix and -suffix")
+	exprSrc := fs.String("expr", "", `expression combining conditions, e.g. 'prefix("shop") && count("7") < 3', instead of -prefix and -suffix`)
+	top := fs.Int("top", 0, "collect matches until -timeout or Ctrl+C and keep the N that read best")
+	count := fs.Int("count", 1, "number of matches to collect, each with its own keys (0 keeps going until interrupted or -timeout)")
+	blockFile := fs.String("blocklist", "", "file of further words no match may contain, one per line")
+	noBlocklist := fs.Bool("no-blocklist", false, "allow matches containing the built-in list of offensive words")
+	cores := fs.Int("cores", runtime.NumCPU(), "number of CPU workers")
+	useGPU := fs.Bool("gpu", false, "also search on the GPU when one is available")
+	gpuDevice := fs.Int("gpu-device", 0, "GPU device index")
+	outDir := fs.String("out", ".", "directory the keys are written to")
+	saveBest := fs.Bool("save-best", false, "keep the keys of the closest candidate so far in vanity_best inside -out")
+	outFormat := fs.String("format", "text", "output format: text or ndjson (see internal/events)")
+	interval := fs.Duration("interval", 2*time.Second, "text progress report interval (0 disables)")
+	timeout := fs.Duration("timeout", 0, "give up after this long (0 means no limit)")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	rep, err := newReporter(*outFormat, *interval)
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return exitUsage
+	}
+
+	scheme, err := address.LookupScheme(*network)
+	if err == nil {
+		scheme, err = withCrypto(scheme, *crypto)
+	}
+	if err != nil {
+		rep.fail(events.CodeInvalidArgument, err)
+		return exitUsage
+	}
+	block, err := loadBlocklist(*blockFile, *noBlocklist)
+	if err != nil {
+		rep.fail(events.CodeInvalidArgument, err)
+		return exitUsage
+	}
+	if *like != "" {
+		if *prefix != "" || *regex != "" || *contains != "" || *exprSrc != "" {
+			rep.fail(events.CodeInvalidArgument, errors.New("-lookalike cannot be combined with -prefix, -regex, -contains or -expr"))
+			return exitUsage
+		}
+		e, err := lookalike.Expand(*like)
+		if err != nil {
+			rep.fail(events.CodeInvalidArgument, err)
+			return exitUsage
+		}
+		*prefix = e.Pattern
+		fmt.Fprintf(stderr, "%q expands to %s, %s lookalike prefixes (%s", *like, e.Pattern, format.Uint(uint64(e.Variants)), strings.Join(e.List(5), ", "))
+		if e.Variants > 5 {
+			fmt.Fprint(stderr, ", ...")
+		}
+		estimate := address.EstimateSearchAttempts(scheme, e.Pattern, *suffix)
+		if block != nil {
+			estimate = block.AdjustAttempts(scheme, e.Pattern, *suffix, estimate)
+		}
+		fmt.Fprintf(stderr, "), about %s attempts\n", format.Uint(uint64(estimate)))
+	}
+	targets := generator.Targets("", strings.Split(*prefix, ","))
+	if len(targets) == 0 {
+		targets = []string{""}
+	}
+	anywhere := *regex != "" || *contains != "" || *exprSrc != ""
+	switch {
+	case *regex != "" && *contains != "", *exprSrc != "" && (*regex != "" || *contains != ""):
+		rep.fail(events.CodeInvalidArgument, errors.New("only one of -regex, -contains and -expr can be used"))
+		return exitUsage
+	case anywhere && (*prefix != "" || *suffix != ""):
+		rep.fail(events.CodeInvalidArgument, errors.New("-regex, -contains and -expr cannot be combined with -prefix or -suffix"))
+		return exitUsage
+	case *exprSrc != "":
+		e, err := expr.Compile(*exprSrc)
+		if err == nil {
+			err = e.Validate(scheme)
+		}
+		if err != nil {
+			rep.fail(events.CodeInvalidArgument, fmt.Errorf("invalid expression: %w", err))
+			return exitUsage
+		}
+	case *regex != "":
+		if err := address.ValidateRegex(scheme, *regex); err != nil {
+			rep.fail(events.CodeInvalidArgument, fmt.Errorf("invalid regex: %w", err))
+			return exitUsage
+		}
+	case *contains != "":
+		if err := address.ValidateContains(scheme, *contains); err != nil {
+			rep.fail(events.CodeInvalidArgument, err)
+			return exitUsage
+		}
+		if err := checkBlocked(block, scheme, *contains, ""); err != nil {
+			rep.fail(events.CodeInvalidArgument, err)
+			return exitUsage
+		}
+	default:
+		for _, t := range targets {
+			if err := address.ValidateSearch(scheme, t, *suffix); err != nil {
+				rep.fail(events.CodeInvalidArgument, err)
+				return exitUsage
+			}
+			if err := checkBlocked(block, scheme, t, *suffix); err != nil {
+				rep.fail(events.CodeInvalidArgument, err)
+				return exitUsage
+			}
+		}
+	}
+	if *count < 0 || *top < 0 {
+		rep.fail(events.CodeInvalidArgument, errors.New("count and top must not be negative"))
+		return exitUsage
+	}
+	if *top > 0 && *count != 1 {
+		rep.fail(events.CodeInvalidArgument, errors.New("-top cannot be combined with -count"))
+		return exitUsage
+	}
+	if *top > 0 {
+		*count = 0 // collect until the budget runs out
+	}
+	if *cores < 0 || *cores > runtime.NumCPU() {
+		rep.fail(events.CodeInvalidArgument, fmt.Errorf("cores must be between 0 and %d", runtime.NumCPU()))
+		return exitUsage
+	}
+	if *useGPU && !gpu.Available() {
+		fmt.Fprintln(stderr, "warning: no GPU available, searching on CPU only")
+		*useGPU = false
+	}
+
+	var reject address.Matcher
+	if block != nil {
+		reject = block
+	}
+	gen, err := generator.NewWithConfig(generator.Config{
+		Scheme:     scheme,
+		Prefixes:   targets,
+		Suffix:     *suffix,
+		Regex:      *regex,
+		Contains:   *contains,
+		Expr:       *exprSrc,
+		Reject:     reject,
+		Continuous: *count != 1,
+		Count:      *count,
+		Cores:      *cores,
+		GPU:        *useGPU,
+		GPUDevice:  *gpuDevice,
+	})
+	if err != nil {
+		rep.fail(events.CodeInvalidArgument, err)
+		return exitUsage
+	}
+	if *useGPU && !gen.UsesGPU() {
+		fmt.Fprintln(stderr, "warning: the GPU only stops at the first match of a single literal prefix, searching on CPU only")
+		*useGPU = false
+	}
+	if *saveBest && !gen.TracksBest() {
+		fmt.Fprintln(stderr, "warning: -save-best only applies to a single-prefix search")
+		*saveBest = false
+	}
+	if *cores == 0 && !*useGPU {
+		rep.fail(events.CodeInvalidArgument, errors.New("nothing to search with (cores is 0 and no GPU)"))
+		return exitUsage
+	}
+
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	var interrupted atomic.Bool
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		if _, ok := <-sigCh; ok {
+			interrupted.Store(true)
+			gen.Stop()
+		}
+	}()
+
+	start := &events.Start{
+		Network:           scheme.Network().String(),
+		Prefix:            targets[0],
+		Suffix:            strings.ToLower(*suffix),
+		Cores:             *cores,
+		GPU:               gen.UsesGPU(),
+		EstimatedAttempts: address.EstimateTargetsAttempts(scheme, targets, *suffix),
+	}
+	if len(targets) > 1 {
+		start.Prefix, start.Prefixes = "", targets
+		if *count != 1 {
+			// Collecting several matches counts matches of any prefix, so
+			// each one comes at the combined rate of all of them.
+			rate := 0.0
+			for _, t := range targets {
+				rate += 1 / address.EstimateSearchAttempts(scheme, t, *suffix)
+			}
+			start.EstimatedAttempts = 1 / rate
+		}
+	}
+	switch {
+	case *regex != "":
+		start.Regex = *regex
+		start.EstimatedAttempts = address.EstimateRegexAttempts(scheme, *regex)
+	case *contains != "":
+		start.Contains = strings.ToLower(*contains)
+		start.EstimatedAttempts = address.EstimateContainsAttempts(scheme, *contains)
+	case *exprSrc != "":
+		start.Expr = *exprSrc
+		start.EstimatedAttempts = expr.MustCompile(*exprSrc).EstimateAttempts(scheme)
+	}
+	if block != nil {
+		start.EstimatedAttempts /= blocklistPass(block, scheme, targets, *suffix, anywhere)
+	}
+	if *count > 1 {
+		start.EstimatedAttempts *= float64(*count)
+	}
+	rep.start(start)
+
+	var saveErr error
+	var best *generator.Result
+	onStats := func(st generator.Stats) {
+		rep.stats(st)
+		if *saveBest && st.Best != nil && st.Best != best && saveErr == nil {
+			best = st.Best
+			if err := saveBestResult(*outDir, scheme.Network(), best.Candidate); err != nil {
+				saveErr = fmt.Errorf("saving best candidate %s: %w", best.Address, err)
+				gen.Stop()
+			}
+		}
+	}
+
+	if *top > 0 {
+		return searchTop(ctx, gen, rep, scheme.Network(), *outDir, *top, len(targets) > 1, onStats, &saveErr, interrupted.Load)
+	}
+
+	resultCh, statsCh := gen.Start(ctx)
+
+	// Keys are saved as each target is matched, so an interrupted
+	// multi-prefix search keeps everything found so far.
+	found := 0
+	for resultCh != nil || statsCh != nil {
+		select {
+		case r, ok := <-resultCh:
+			if !ok {
+				resultCh = nil
+				continue
+			}
+			found++
+			path, err := saveResult(*outDir, scheme.Network(), r.Candidate)
+			if err != nil {
+				saveErr = fmt.Errorf("saving keys for %s: %w", r.Address, err)
+				gen.Stop()
+				continue
+			}
+			ev := &events.Result{
+				Network:     scheme.Network().String(),
+				Address:     r.Address,
+				Attempts:    r.Attempts,
+				DurationSec: r.Duration.Seconds(),
+				SavedPaths:  []string{path},
+			}
+			if len(targets) > 1 {
+				ev.Target = r.Target
+			}
+			rep.result(ev)
+		case st, ok := <-statsCh:
+			if !ok {
+				statsCh = nil
+				continue
+			}
+			onStats(st)
+		}
+	}
+
+	if *count != 1 {
+		switch {
+		case saveErr != nil:
+			rep.fail(events.CodeSaveFailed, saveErr)
+			return exitError
+		case found > 0 && (*count == 0 || found == *count):
+			return exitOK
+		case interrupted.Load():
+			rep.fail(events.CodeInterrupted, errors.New("interrupted"))
+			return exitInterrupted
+		case found > 0:
+			rep.fail(events.CodeNotFound, fmt.Errorf("only %d of %d matches found before the search ended", found, *count))
+			return exitNotFound
+		}
+		rep.fail(events.CodeNotFound, errors.New("no match found before the search ended"))
+		return exitNotFound
+	}
+
+	switch {
+	case saveErr != nil:
+		rep.fail(events.CodeSaveFailed, saveErr)
+		return exitError
+	case found == len(targets):
+		return exitOK
+	case interrupted.Load():
+		rep.fail(events.CodeInterrupted, errors.New("interrupted"))
+		return exitInterrupted
+	case found > 0:
+		rep.fail(events.CodeNotFound, fmt.Errorf("only %d of %d prefixes matched before the search ended", found, len(targets)))
+		return exitNotFound
+	}
+	rep.fail(events.CodeNotFound, errors.New("no match found before the search ended"))
+	return exitNotFound
+}
+
+// searchTop runs gen until ctx ends or it is stopped, then saves and
+// reports the k matches that read best, best first. multi names the
+// matched prefix in each result.
+func searchTop(ctx context.Context, gen *generator.Generator, rep searchReporter, network address.Network, outDir string, k int, multi bool, onStats func(generator.Stats), saveErr *error, interrupted func() bool) int {
+	entries := score.Collect(ctx, gen, k, onStats)
+	if *saveErr != nil {
+		rep.fail(events.CodeSaveFailed, *saveErr)
+		return exitError
+	}
+	for _, e := range entries {
+		path, err := saveResult(outDir, network, e.Result.Candidate)
+		if err != nil {
+			rep.fail(events.CodeSaveFailed, fmt.Errorf("saving keys for %s: %w", e.Result.Address, err))
+			return exitError
+		}
+		total := e.Score.Total
+		ev := &events.Result{
+			Network:     network.String(),
+			Address:     e.Result.Address,
+			Attempts:    e.Result.Attempts,
+			DurationSec: e.Result.Duration.Seconds(),
+			SavedPaths:  []string{path},
+			Score:       &total,
+		}
+		if multi {
+			ev.Target = e.Result.Target
+		}
+		rep.result(ev)
+	}
+	switch {
+	case len(entries) > 0:
+		return exitOK
+	case interrupted():
+		rep.fail(events.CodeInterrupted, errors.New("interrupted"))
+		return exitInterrupted
+	}
+	rep.fail(events.CodeNotFound, errors.New("no match found before the search ended"))
+	return exitNotFound
+}
+
+// loadBlocklist returns the built-in blocklist, unless disabled, extended
+// by the words in file, if any. It returns nil when nothing is blocked.
+func loadBlocklist(file string, noBuiltin bool) (*blocklist.Blocklist, error) {
+	var words []string
+	if file != "" {
+		var err error
+		if words, err = blocklist.Load(file); err != nil {
+			return nil, fmt.Errorf("reading blocklist: %w", err)
+		}
+	}
+	switch {
+	case !noBuiltin && len(words) > 0:
+		return blocklist.Default().With(words)
+	case !noBuiltin:
+		return blocklist.Default(), nil
+	case len(words) > 0:
+		return blocklist.New(words)
+	}
+	return nil, nil
+}
+
+// checkBlocked rejects a prefix and suffix that block lets no address
+// through for, naming the blocked word when one is spelled out.
+func checkBlocked(block *blocklist.Blocklist, scheme address.Scheme, prefix, suffix string) error {
+	if block == nil || block.PassProbability(scheme, prefix, suffix) > 0 {
+		return nil
+	}
+	for _, s := range []string{prefix, suffix} {
+		if w := block.Find(s); w != "" {
+			return fmt.Errorf("%q contains the blocked word %q (see -no-blocklist)", s, w)
+		}
+	}
+	return fmt.Errorf("every address matching prefix %q and suffix %q contains a blocked word (see -no-blocklist)", prefix, suffix)
+}
+
+// blocklistPass returns the share of matches block lets through. It is exact
+// for prefix searches, taking the lowest share among several targets; for
+// regex, contains and expression searches (anywhere) it is the share of all
+// addresses.
+func blocklistPass(block *blocklist.Blocklist, scheme address.Scheme, targets []string, suffix string, anywhere bool) float64 {
+	if anywhere {
+		return block.PassProbability(scheme, "", "")
+	}
+	pass := 1.0
+	for _, t := range targets {
+		pass = min(pass, block.PassProbability(scheme, t, suffix))
+	}
+	return pass
+}
+
+// keyPath returns where the keys for cand are written inside dir, using the
+// same vanity_<address> naming as the GUI.
+func keyPath(dir string, network address.Network, cand address.Candidate) string {
+	addr := cand.Address()
+	if len(addr) > 16 {
+		addr = addr[:16]
+	}
+	if network == address.NetworkTorV3 {
+		// Tor v3: a hidden service directory
+		return filepath.Join(dir, "vanity_"+addr)
+	}
+	return filepath.Join(dir, "vanity_"+addr+".dat")
+}
+
+// saveBestResult writes cand's keys to vanity_best in dir, replacing the
+// previous best candidate.
+func saveBestResult(dir string, network address.Network, cand address.Candidate) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+	path := filepath.Join(dir, "vanity_best")
+	if network != address.NetworkTorV3 {
+		path += ".dat"
+	}
+	return cand.SaveKeys(path)
+}
+
+// saveResult writes cand's keys into dir and returns the path written. Keys
+// never replace existing ones: when the name from keyPath is taken, as it is
+// for two matches sharing their first 16 characters, a numbered name is used.
+func saveResult(dir string, network address.Network, cand address.Candidate) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("creating directory: %w", err)
+	}
+	path, err := reservePath(keyPath(dir, network, cand), network)
+	if err != nil {
+		return "", err
+	}
+	if err := cand.SaveKeys(path); err != nil {
+		os.RemoveAll(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// reservePath claims path, or the first free one of path_2, path_3, ...
+// (before any extension), by creating it exclusively: an empty file for
+// I2P keys, a directory for a Tor hidden service.
+func reservePath(path string, network address.Network) (string, error) {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for n := 1; ; n++ {
+		p := path
+		if n > 1 {
+			p = fmt.Sprintf("%s_%d%s", base, n, ext)
+		}
+		var err error
+		if network == address.NetworkTorV3 {
+			err = os.Mkdir(p, 0700)
+		} else {
+			var f *os.File
+			if f, err = os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600); err == nil {
+				err = f.Close()
+			}
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return p, err
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/address"
+	"github.com/go-i2p/i2p-vanitygen/internal/keyfile"
+)
+
+func captureOutput(t *testing.T) (*bytes.Buffer, *bytes.Buffer) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	oldOut, oldErr := stdout, stderr
+	stdout, stderr = &out, &errOut
+	t.Cleanup(func() { stdout, stderr = oldOut, oldErr })
+	return &out, &errOut
+}
+
+func TestSearchSavesKeys(t *testing.T) {
+	out, _ := captureOutput(t)
+	dir := t.TempDir()
+
+	for _, network := range []string{"i2p", "torv3"} {
+		out.Reset()
+		code := Run([]string{"search", "-network", network, "-prefix", "a", "-cores", "1", "-out", dir, "-interval", "0"})
+		if code != exitOK {
+			t.Fatalf("%s: exit code %d", network, code)
+		}
+		addr := strings.TrimSpace(out.String())
+		if !strings.HasPrefix(addr, "a") {
+			t.Errorf("%s: address %q does not start with prefix", network, addr)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("expected 2 saved key sets, got %d", len(entries))
+	}
+}
+
+func TestSearchCount(t *testing.T) {
+	out, _ := captureOutput(t)
+	dir := t.TempDir()
+
+	code := Run([]string{"search", "-prefix", "a", "-count", "3", "-cores", "1", "-out", dir, "-interval", "0"})
+	if code != exitOK {
+		t.Fatalf("exit code %d", code)
+	}
+	if addrs := strings.Fields(out.String()); len(addrs) != 3 {
+		t.Errorf("printed %d addresses, want 3", len(addrs))
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("expected 3 saved key sets, got %d", len(entries))
+	}
+}
+
+func TestSaveResultKeepsCollidingNames(t *testing.T) {
+	for _, tt := range []struct {
+		network address.Network
+		scheme  address.Scheme
+	}{
+		{address.NetworkI2P, address.I2PScheme{}},
+		{address.NetworkTorV3, address.TorV3Scheme{}},
+	} {
+		cand, err := tt.scheme.NewCandidate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		dir := t.TempDir()
+		first, err := saveResult(dir, tt.network, cand)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := saveResult(dir, tt.network, cand)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := strings.TrimSuffix(first, filepath.Ext(first)) + "_2" + filepath.Ext(first)
+		if second != want {
+			t.Errorf("%s: second save went to %s, want %s", tt.network, second, want)
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 2 {
+			t.Errorf("%s: expected 2 saved key sets, got %d", tt.network, len(entries))
+		}
+	}
+}
+
+func TestSearchTop(t *testing.T) {
+	out, _ := captureOutput(t)
+	dir := t.TempDir()
+
+	code := Run([]string{"search", "-prefix", "a", "-top", "2", "-timeout", "500ms", "-cores", "1", "-out", dir, "-format", "ndjson"})
+	if code != exitOK {
+		t.Fatalf("exit code %d", code)
+	}
+	var results int
+	for _, line := range strings.Split(out.String(), "\n") {
+		if strings.Contains(line, `"type":"result"`) {
+			results++
+			if !strings.Contains(line, `"score":`) {
+				t.Errorf("result without a score: %s", line)
+			}
+		}
+	}
+	if results != 2 {
+		t.Errorf("got %d results, want 2", results)
+	}
+}
+
+func TestSearchExpr(t *testing.T) {
+	out, _ := captureOutput(t)
+	dir := t.TempDir()
+
+	code := Run([]string{"search", "-expr", `prefix("a") && !suffix("q")`, "-cores", "1", "-out", dir, "-interval", "0"})
+	if code != exitOK {
+		t.Fatalf("exit code %d", code)
+	}
+	addr := strings.TrimSpace(out.String())
+	if !strings.HasPrefix(addr, "a") || strings.HasSuffix(addr, "q.b32.i2p") {
+		t.Errorf("address %q does not satisfy the expression", addr)
+	}
+}
+
+func TestSearchX25519(t *testing.T) {
+	out, _ := captureOutput(t)
+	dir := t.TempDir()
+
+	code := Run([]string{"search", "-crypto", "x25519", "-prefix", "ab", "-cores", "1", "-out", dir, "-interval", "0"})
+	if code != exitOK {
+		t.Fatalf("exit code %d", code)
+	}
+	addr := strings.TrimSpace(out.String())
+	infos, err := keyfile.Inspect(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The mutated padding leaves the X25519 key intact and is saved with it.
+	if len(infos) != 1 || !infos[0].OK() || infos[0].Address != addr {
+		t.Errorf("saved keys for %s: %+v", addr, infos[0])
+	}
+}
+
+func TestSearchUsageErrors(t *testing.T) {
+	captureOutput(t)
+
+	tests := [][]string{
+		{"search", "-prefix", "abc1"},
+		{"search", "-network", "tor2", "-prefix", "abc"},
+		{"search", "-prefix", "abc", "-cores", "0"},
+		{"search", "-prefix", "abc", "-count", "-1"},
+		{"search", "-prefix", "abc", "-count", "3", "-top", "2"},
+		{"search", "-lookalike", "he11o", "-prefix", "abc"},
+		{"search", "-lookalike", "he-llo"},
+		{"search", "-prefix", "shit"},
+		{"search", "-prefix", "a,b,porn"},
+		{"search", "-contains", "xxcuntxx"},
+		{"search", "-prefix", "abc", "-blocklist", "/nonexistent/words.txt"},
+		{"search", "-expr", `prefix("a") &&`},
+		{"search", "-expr", `suffix("b")`},
+		{"search", "-expr", `prefix("a")`, "-prefix", "b"},
+		{"search", "-expr", `prefix("a")`, "-regex", "^a"},
+		{"search", "-crypto", "rsa", "-prefix", "abc"},
+		{"search", "-network", "torv3", "-crypto", "x25519", "-prefix", "abc"},
+		{"search", "-bogus"},
+		{"nosuchcommand"},
+	}
+	for _, args := range tests {
+		if code := Run(args); code != exitUsage {
+			t.Errorf("Run(%q) = %d, want %d", args, code, exitUsage)
+		}
+	}
+}
+
+func TestSearchTimeout(t *testing.T) {
+	captureOutput(t)
+	code := Run([]string{"search", "-prefix", "zzzzzzzz", "-cores", "1", "-timeout", "200ms", "-out", t.TempDir()})
+	if code != exitNotFound {
+		t.Errorf("exit code %d, want %d", code, exitNotFound)
+	}
+}
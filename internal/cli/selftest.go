@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/selftest"
+)
+
+func runSelftest(args []string) int {
+	fs := flag.NewFlagSet("selftest", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	useGPU := fs.Bool("gpu", true, "also compare the GPU kernels against the CPU when a GPU is present")
+	gpuDevice := fs.Int("gpu-device", 0, "GPU device index")
+	asJSON := fs.Bool("json", false, "print one JSON object per component instead of text")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	results := selftest.Run(selftest.Options{GPU: *useGPU, GPUDevice: *gpuDevice})
+	enc := json.NewEncoder(stdout)
+	for _, r := range results {
+		if *asJSON {
+			enc.Encode(r)
+			continue
+		}
+		line := fmt.Sprintf("%-4s  %s", strings.ToUpper(string(r.Status)), r.Component)
+		if r.Detail != "" {
+			line += ": " + r.Detail
+		}
+		fmt.Fprintln(stdout, line)
+	}
+	if !selftest.Passed(results) {
+		return exitError
+	}
+	return exitOK
+}
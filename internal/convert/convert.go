@@ -0,0 +1,303 @@
+// Package convert re-encodes saved identities for other consumers: I2P
+// routers and SAM clients, Tor's control port, and PEM-based tooling.
+// Every conversion is read back and checked to describe the same address
+// before it is written.
+package convert
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/address"
+	"github.com/go-i2p/i2p-vanitygen/internal/destination"
+	"github.com/go-i2p/i2p-vanitygen/internal/keyfile"
+)
+
+// Format names an output encoding.
+type Format string
+
+const (
+	// FormatI2PD is the binary I2P private key file: destination, encryption
+	// private key (256 bytes for ElGamal, 32 for X25519) and signing seed.
+	// i2pd, the Java router and this tool's .dat files all use this layout.
+	FormatI2PD Format = "i2pd"
+	// FormatI2PBase64 is the same key file in I2P base64, the private key
+	// string used by SAM ("DEST GENERATE") and tunnel configs.
+	FormatI2PBase64 Format = "i2p-b64"
+	// FormatTorDir is a hidden service directory as read by tor.
+	FormatTorDir Format = "tor-dir"
+	// FormatAddOnion is the "ED25519-V3:<base64>" key blob for the control
+	// port's ADD_ONION command.
+	FormatAddOnion Format = "add-onion"
+	// FormatPEM is a PKCS#8 "PRIVATE KEY" block. It needs the Ed25519 seed,
+	// so it is only available for I2P identities; Tor vanity keys are found
+	// by stepping the scalar and have no seed.
+	FormatPEM Format = "pem"
+	// FormatPEMPublic is a PKIX "PUBLIC KEY" block with the Ed25519 signing key.
+	FormatPEMPublic Format = "pem-public"
+)
+
+// Formats lists the output formats for a network.
+func Formats(network address.Network) []Format {
+	if network == address.NetworkTorV3 {
+		return []Format{FormatTorDir, FormatAddOnion, FormatPEMPublic}
+	}
+	return []Format{FormatI2PD, FormatI2PBase64, FormatPEM, FormatPEMPublic}
+}
+
+const addOnionPrefix = "ED25519-V3:"
+
+// Identity is one loaded key set. Exactly one of Dest and Tor is set.
+type Identity struct {
+	Dest *destination.Destination
+	Tor  *address.TorV3Candidate
+}
+
+// Network returns the identity's network.
+func (id *Identity) Network() address.Network {
+	if id.Tor != nil {
+		return address.NetworkTorV3
+	}
+	return address.NetworkI2P
+}
+
+// Address returns the full .b32.i2p or .onion address.
+func (id *Identity) Address() string {
+	if id.Tor != nil {
+		return id.Tor.FullAddress()
+	}
+	return id.Dest.FullB32Address()
+}
+
+func (id *Identity) publicKey() ed25519.PublicKey {
+	if id.Tor != nil {
+		return id.Tor.PublicKeyBytes()
+	}
+	return id.Dest.SigningPublicKey()
+}
+
+// Load reads an identity from a hidden service directory, an
+// hs_ed25519_secret_key file, or any single-file format accepted by Parse.
+func Load(path string) (*Identity, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		path = filepath.Join(path, keyfile.TorSecretKeyFile)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	id, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return id, nil
+}
+
+// Parse decodes a binary I2P key file, an I2P base64 private key string, an
+// ADD_ONION key blob or the contents of hs_ed25519_secret_key.
+func Parse(data []byte) (*Identity, error) {
+	hdr := address.TorV3SecretKeyHeader
+	switch {
+	case len(data) == destination.KeysFileSize, len(data) == destination.X25519KeysFileSize:
+		return parseI2P(data)
+	case len(data) == len(hdr)+64 && bytes.HasPrefix(data, []byte(hdr)):
+		return parseTorExpanded(data[len(hdr):])
+	}
+
+	text := strings.TrimSpace(string(data))
+	if blob, ok := strings.CutPrefix(text, addOnionPrefix); ok {
+		expanded, err := base64.StdEncoding.DecodeString(blob)
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s key: %w", addOnionPrefix, err)
+		}
+		return parseTorExpanded(expanded)
+	}
+	d, err := destination.ParsePrivateKeyBase64(text)
+	if err != nil {
+		return nil, errors.New("not a recognised key format (I2P .dat or base64, Tor secret key or ADD_ONION blob)")
+	}
+	return checkI2P(d)
+}
+
+func parseI2P(data []byte) (*Identity, error) {
+	d, err := destination.ParseKeys(data)
+	if err != nil {
+		return nil, err
+	}
+	return checkI2P(d)
+}
+
+// checkI2P rejects key files whose signing seed does not belong to the
+// destination; converting them would silently produce unusable keys.
+func checkI2P(d *destination.Destination) (*Identity, error) {
+	if !bytes.Equal(d.SigningPrivateKey.Public().(ed25519.PublicKey), d.SigningPublicKey()) {
+		return nil, errors.New("signing seed does not match the destination")
+	}
+	return &Identity{Dest: d}, nil
+}
+
+func parseTorExpanded(expanded []byte) (*Identity, error) {
+	cand, err := address.TorV3CandidateFromExpanded(expanded)
+	if err != nil {
+		return nil, err
+	}
+	return &Identity{Tor: cand}, nil
+}
+
+// Encode returns id in a single-file format. FormatTorDir is a directory and
+// must be written with Write.
+func Encode(id *Identity, f Format) ([]byte, error) {
+	if !slices.Contains(Formats(id.Network()), f) {
+		return nil, fmt.Errorf("format %q is not available for %s identities (available: %s)", f, id.Network(), formatList(id.Network()))
+	}
+	switch f {
+	case FormatI2PD:
+		return id.Dest.MarshalKeys(), nil
+	case FormatI2PBase64:
+		return []byte(id.Dest.PrivateKeyBase64() + "\n"), nil
+	case FormatAddOnion:
+		return []byte(addOnionPrefix + base64.StdEncoding.EncodeToString(id.Tor.SecretKeyBytes()) + "\n"), nil
+	case FormatPEM:
+		der, err := x509.MarshalPKCS8PrivateKey(id.Dest.SigningPrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+	case FormatPEMPublic:
+		der, err := x509.MarshalPKIXPublicKey(id.publicKey())
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+	}
+	return nil, fmt.Errorf("format %q cannot be encoded to a single file", f)
+}
+
+// Write converts id to format f at path after checking that the result
+// reads back as the same identity. An existing path is only replaced if
+// force is set; otherwise the error wraps fs.ErrExist.
+func Write(id *Identity, f Format, path string, force bool) error {
+	if f == FormatTorDir {
+		return writeTorDir(id, path, force)
+	}
+
+	data, err := Encode(id, f)
+	if err != nil {
+		return err
+	}
+	if err := check(id, f, data); err != nil {
+		return err
+	}
+	if force {
+		return os.WriteFile(path, data, 0600)
+	}
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := out.Write(data); err != nil {
+		out.Close()
+		os.Remove(path)
+		return err
+	}
+	return out.Close()
+}
+
+// writeTorDir writes the hidden service directory next to path, reads it back
+// and only then moves it into place.
+func writeTorDir(id *Identity, path string, force bool) error {
+	if id.Tor == nil {
+		return fmt.Errorf("format %q is not available for %s identities (available: %s)", FormatTorDir, id.Network(), formatList(id.Network()))
+	}
+	if _, err := os.Lstat(path); err == nil && !force {
+		return &fs.PathError{Op: "write", Path: path, Err: fs.ErrExist}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(path), filepath.Base(path)+".partial")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	if err := id.Tor.SaveKeys(tmp); err != nil {
+		return err
+	}
+	back, err := Load(tmp)
+	if err != nil {
+		return fmt.Errorf("reading back %s: %w", path, err)
+	}
+	if err := sameAddress(id, back); err != nil {
+		return err
+	}
+	if force {
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp, path)
+}
+
+// check decodes data again and compares it with id.
+func check(id *Identity, f Format, data []byte) error {
+	if f != FormatPEM && f != FormatPEMPublic {
+		back, err := Parse(data)
+		if err != nil {
+			return fmt.Errorf("round trip: %w", err)
+		}
+		return sameAddress(id, back)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return errors.New("round trip: no PEM block")
+	}
+	var pub any
+	if f == FormatPEM {
+		priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("round trip: %w", err)
+		}
+		if k, ok := priv.(ed25519.PrivateKey); ok {
+			pub = k.Public()
+		}
+	} else {
+		var err error
+		if pub, err = x509.ParsePKIXPublicKey(block.Bytes); err != nil {
+			return fmt.Errorf("round trip: %w", err)
+		}
+	}
+	if k, ok := pub.(ed25519.PublicKey); !ok || !k.Equal(id.publicKey()) {
+		return errors.New("round trip: PEM key does not match the identity's signing key")
+	}
+	return nil
+}
+
+func sameAddress(want, got *Identity) error {
+	if got.Address() != want.Address() {
+		return fmt.Errorf("round trip changed the address: %s became %s", want.Address(), got.Address())
+	}
+	return nil
+}
+
+func formatList(network address.Network) string {
+	var names []string
+	for _, f := range Formats(network) {
+		names = append(names, string(f))
+	}
+	return strings.Join(names, ", ")
+}
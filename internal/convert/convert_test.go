@@ -0,0 +1,113 @@
+package convert
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/address"
+)
+
+func TestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	i2p, err := address.I2PScheme{}.NewCandidate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	i2pPath := filepath.Join(dir, "src.dat")
+	if err := i2p.SaveKeys(i2pPath); err != nil {
+		t.Fatal(err)
+	}
+	tor, err := address.NewTorV3Candidate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tor.AdvanceBy(42)
+	torPath := filepath.Join(dir, "src-hs")
+	if err := tor.SaveKeys(torPath); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, src := range []string{i2pPath, torPath} {
+		id, err := Load(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range Formats(id.Network()) {
+			out := filepath.Join(dir, filepath.Base(src)+"."+string(f))
+			if err := Write(id, f, out, false); err != nil {
+				t.Fatalf("%s -> %s: %v", src, f, err)
+			}
+			if f == FormatPEM || f == FormatPEMPublic {
+				continue // not an identity on its own
+			}
+			back, err := Load(out)
+			if err != nil {
+				t.Fatalf("%s: %v", out, err)
+			}
+			if back.Address() != id.Address() {
+				t.Errorf("%s: address %s, want %s", out, back.Address(), id.Address())
+			}
+		}
+	}
+}
+
+func TestWriteRefusesToOverwrite(t *testing.T) {
+	tor, err := address.NewTorV3Candidate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := address.NewTorV3Candidate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, f := range []Format{FormatTorDir, FormatAddOnion} {
+		out := filepath.Join(dir, string(f))
+		if err := Write(&Identity{Tor: tor}, f, out, false); err != nil {
+			t.Fatal(err)
+		}
+		if err := Write(&Identity{Tor: other}, f, out, false); !errors.Is(err, fs.ErrExist) {
+			t.Errorf("%s: second write returned %v, want fs.ErrExist", f, err)
+		}
+		if back, err := Load(out); err != nil || back.Address() != tor.FullAddress() {
+			t.Errorf("%s: refused write changed the output (%v)", f, err)
+		}
+		if err := Write(&Identity{Tor: other}, f, out, true); err != nil {
+			t.Fatalf("%s: forced write: %v", f, err)
+		}
+		if back, err := Load(out); err != nil || back.Address() != other.FullAddress() {
+			t.Errorf("%s: forced write did not replace the output (%v)", f, err)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("left %d entries behind, want 2", len(entries))
+	}
+}
+
+func TestUnsupportedFormat(t *testing.T) {
+	tor, err := address.NewTorV3Candidate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := &Identity{Tor: tor}
+	if err := Write(id, FormatPEM, filepath.Join(t.TempDir(), "x.pem"), false); err == nil {
+		t.Error("PKCS#8 export of a seedless Tor key succeeded")
+	}
+	if err := Write(id, FormatI2PD, filepath.Join(t.TempDir(), "x.dat"), false); err == nil {
+		t.Error("I2P export of a Tor key succeeded")
+	}
+}
+
+func TestParseRejectsGarbage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "junk")
+	if err := os.WriteFile(path, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load accepted garbage")
+	}
+}
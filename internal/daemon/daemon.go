@@ -0,0 +1,555 @@
+// Package daemon serves a bearer-token protected HTTP/JSON API that queues
+// vanity searches and runs them with a configurable concurrency.
+//
+// Endpoints (all require "Authorization: Bearer <token>"):
+//
+//	POST   /v1/jobs             submit a search; body is a jobs.Job without "output"
+//	GET    /v1/jobs             list all jobs
+//	GET    /v1/jobs/{id}        one job
+//	DELETE /v1/jobs/{id}        cancel a queued or running job
+//	GET    /v1/jobs/{id}/events server-sent events (see package events) until the job ends
+//	GET    /v1/jobs/{id}/keys   the saved key files, base64 encoded by file name
+//
+// Only the most recent Config.KeepFinished finished jobs are remembered; older
+// ones drop out of the API, though their key files stay in the output
+// directory.
+package daemon
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/address"
+	"github.com/go-i2p/i2p-vanitygen/internal/events"
+	"github.com/go-i2p/i2p-vanitygen/internal/generator"
+	"github.com/go-i2p/i2p-vanitygen/internal/jobs"
+)
+
+// maxQueued bounds the number of jobs waiting to run.
+const maxQueued = 1024
+
+// defaultKeepFinished is used when Config.KeepFinished is 0.
+const defaultKeepFinished = 1024
+
+// Job states reported by the API.
+const (
+	StateQueued   = "queued"
+	StateRunning  = "running"
+	StateFound    = "found"
+	StateNotFound = "not_found"
+	StateFailed   = "failed"
+	StateCanceled = "canceled"
+)
+
+// Config configures a Server.
+type Config struct {
+	Token       string   // required bearer token
+	OutDir      string   // where key files are written, one entry per job ID
+	Concurrency int      // number of searches run at once
+	Defaults    jobs.Job // applied to fields a submission leaves unset
+	// KeepFinished is how many finished jobs the API remembers; 0 means 1024.
+	KeepFinished int
+}
+
+// Server is an http.Handler backed by a job queue.
+type Server struct {
+	cfg   Config
+	mux   *http.ServeMux
+	queue chan *job
+
+	mu    sync.Mutex
+	jobs  map[string]*job
+	order []string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// JobView is the JSON representation of a job.
+type JobView struct {
+	ID          string     `json:"id"`
+	State       string     `json:"state"`
+	Network     string     `json:"network"`
+	Prefix      string     `json:"prefix"`
+	Suffix      string     `json:"suffix,omitempty"`
+	Regex       string     `json:"regex,omitempty"`
+	Contains    string     `json:"contains,omitempty"`
+	Expr        string     `json:"expr,omitempty"`
+	Cores       int        `json:"cores"`
+	GPU         bool       `json:"gpu"`
+	Checked     uint64     `json:"checked"`
+	KeysPerSec  float64    `json:"keys_per_sec"`
+	Address     string     `json:"address,omitempty"`
+	Attempts    uint64     `json:"attempts,omitempty"`
+	DurationSec float64    `json:"duration_sec,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	Created     time.Time  `json:"created"`
+	Started     *time.Time `json:"started,omitempty"`
+	Finished    *time.Time `json:"finished,omitempty"`
+}
+
+type job struct {
+	spec jobs.Job
+
+	// ctx is canceled by DELETE, by Close and once the job finishes.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	canceled bool // set by DELETE: a match that comes in anyway is discarded
+	view     JobView
+	final    events.Event // result or error once finished
+	subs     map[chan events.Event]struct{}
+	done     chan struct{}
+}
+
+// New creates a Server and starts its workers. Call Close to stop them.
+func New(cfg Config) (*Server, error) {
+	if cfg.Token == "" {
+		return nil, errors.New("a bearer token is required")
+	}
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	if cfg.KeepFinished < 1 {
+		cfg.KeepFinished = defaultKeepFinished
+	}
+	if err := os.MkdirAll(cfg.OutDir, 0700); err != nil {
+		return nil, fmt.Errorf("creating output directory: %w", err)
+	}
+
+	s := &Server{
+		cfg:   cfg,
+		mux:   http.NewServeMux(),
+		queue: make(chan *job, maxQueued),
+		jobs:  make(map[string]*job),
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	s.mux.HandleFunc("POST /v1/jobs", s.handleSubmit)
+	s.mux.HandleFunc("GET /v1/jobs", s.handleList)
+	s.mux.HandleFunc("GET /v1/jobs/{id}", s.handleGet)
+	s.mux.HandleFunc("DELETE /v1/jobs/{id}", s.handleCancel)
+	s.mux.HandleFunc("GET /v1/jobs/{id}/events", s.handleEvents)
+	s.mux.HandleFunc("GET /v1/jobs/{id}/keys", s.handleKeys)
+
+	for i := 0; i < cfg.Concurrency; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+	return s, nil
+}
+
+// Close cancels every queued and running job and waits for the workers.
+func (s *Server) Close() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// ServeHTTP authenticates the request and dispatches it.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.Token)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="i2p-vanitygen"`)
+		writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) worker() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.ctx.Done():
+			s.drain()
+			return
+		case j := <-s.queue:
+			s.run(j)
+		}
+	}
+}
+
+// drain marks jobs still waiting in the queue as canceled on shutdown.
+func (s *Server) drain() {
+	for {
+		select {
+		case j := <-s.queue:
+			j.finish(StateCanceled, &events.Error{Code: events.CodeCanceled, Message: "daemon shutting down"})
+		default:
+			return
+		}
+	}
+}
+
+func (s *Server) run(j *job) {
+	// The state check and the move to running happen under one lock, so a
+	// DELETE either finishes the job here or cancels its context below.
+	j.mu.Lock()
+	if j.view.State != StateQueued {
+		j.mu.Unlock()
+		return // canceled while queued
+	}
+	if j.ctx.Err() != nil {
+		j.finishLocked(StateCanceled, &events.Error{Code: events.CodeCanceled, Message: "job canceled"})
+		j.mu.Unlock()
+		return
+	}
+	now := time.Now().UTC()
+	j.view.State = StateRunning
+	j.view.Started = &now
+	j.mu.Unlock()
+
+	attempts := j.spec.EstimatedAttempts()
+	runner := &jobs.Runner{
+		OnStats: func(_ jobs.Job, st generator.Stats) {
+			j.mu.Lock()
+			j.view.Checked = st.Checked
+			j.view.KeysPerSec = st.KeysPerSec
+			j.mu.Unlock()
+			j.publish(events.NewStats(st, attempts))
+		},
+	}
+	res := runner.Run(j.ctx, []jobs.Job{j.spec})[0]
+
+	j.mu.Lock()
+	if j.canceled && res.Status == jobs.StatusFound {
+		// The match came in as the job was canceled: a canceled job keeps
+		// no keys. A shutdown, by contrast, keeps a match it already has.
+		os.RemoveAll(j.spec.Output)
+		res = jobs.Result{Status: jobs.StatusInterrupted}
+	}
+	j.view.Address = res.Address
+	j.view.Attempts = res.Attempts
+	j.view.DurationSec = res.DurationSec
+	j.view.Error = res.Error
+	j.mu.Unlock()
+
+	switch res.Status {
+	case jobs.StatusFound:
+		j.finish(StateFound, &events.Result{
+			Network:     j.spec.Network,
+			Address:     res.Address,
+			Attempts:    res.Attempts,
+			DurationSec: res.DurationSec,
+			SavedPaths:  []string{j.spec.Output},
+		})
+	case jobs.StatusNotFound:
+		j.finish(StateNotFound, &events.Error{Code: events.CodeNotFound, Message: "no match found before the job's limits were reached"})
+	case jobs.StatusInterrupted:
+		j.finish(StateCanceled, &events.Error{Code: events.CodeCanceled, Message: "job canceled"})
+	default:
+		code := events.CodeInternal
+		if strings.HasPrefix(res.Error, "saving keys") {
+			code = events.CodeSaveFailed
+		}
+		j.finish(StateFailed, &events.Error{Code: code, Message: res.Error})
+	}
+}
+
+// publish sends ev to every subscriber, dropping it for slow ones.
+func (j *job) publish(ev events.Event) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for ch := range j.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// finish records the final state and closes all subscriptions.
+func (j *job) finish(state string, final events.Event) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.finishLocked(state, final)
+}
+
+// finishLocked is finish with j.mu already held.
+func (j *job) finishLocked(state string, final events.Event) {
+	j.cancel()
+	if j.final != nil {
+		return
+	}
+	now := time.Now().UTC()
+	j.view.State = state
+	j.view.Finished = &now
+	j.final = final
+	for ch := range j.subs {
+		close(ch)
+	}
+	j.subs = nil
+	close(j.done)
+}
+
+func (s *Server) lookup(w http.ResponseWriter, r *http.Request) *job {
+	s.mu.Lock()
+	j := s.jobs[r.PathValue("id")]
+	s.mu.Unlock()
+	if j == nil {
+		writeError(w, http.StatusNotFound, "no such job")
+	}
+	return j
+}
+
+func (s *Server) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	var spec jobs.Job
+	dec := json.NewDecoder(io.LimitReader(r.Body, 1<<16))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&spec); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid job: "+err.Error())
+		return
+	}
+	if spec.Output != "" {
+		writeError(w, http.StatusBadRequest, "output is chosen by the daemon")
+		return
+	}
+
+	id, err := newID()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	spec.Name = id
+	network := spec.Network
+	if network == "" {
+		network = s.cfg.Defaults.Network
+	}
+	spec.Output = id
+	if scheme, err := address.LookupScheme(network); err == nil && scheme.Network() == address.NetworkI2P {
+		spec.Output = id + ".dat"
+	}
+	spec, err = jobs.Resolve(spec, s.cfg.Defaults, s.cfg.OutDir)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	j := &job{
+		spec:   spec,
+		ctx:    ctx,
+		cancel: cancel,
+		subs:   make(map[chan events.Event]struct{}),
+		done:   make(chan struct{}),
+		view: JobView{
+			ID:       id,
+			State:    StateQueued,
+			Network:  spec.Network,
+			Prefix:   spec.Prefix,
+			Suffix:   spec.Suffix,
+			Regex:    spec.Regex,
+			Contains: spec.Contains,
+			Expr:     spec.Expr,
+			Cores:    *spec.Cores,
+			GPU:      spec.GPU != nil && *spec.GPU,
+			Created:  time.Now().UTC(),
+		},
+	}
+
+	s.mu.Lock()
+	select {
+	case s.queue <- j:
+		s.jobs[id] = j
+		s.order = append(s.order, id)
+		s.pruneLocked()
+	default:
+		s.mu.Unlock()
+		cancel()
+		writeError(w, http.StatusServiceUnavailable, "job queue is full")
+		return
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, j.snapshot())
+}
+
+// pruneLocked forgets the oldest finished jobs beyond cfg.KeepFinished.
+// s.mu must be held.
+func (s *Server) pruneLocked() {
+	finished := 0
+	for _, id := range s.order {
+		if s.jobs[id].isFinished() {
+			finished++
+		}
+	}
+	order := s.order[:0]
+	for _, id := range s.order {
+		if finished > s.cfg.KeepFinished && s.jobs[id].isFinished() {
+			delete(s.jobs, id)
+			finished--
+			continue
+		}
+		order = append(order, id)
+	}
+	s.order = order
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	list := make([]JobView, 0, len(s.order))
+	for _, id := range s.order {
+		list = append(list, s.jobs[id].snapshot())
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	if j := s.lookup(w, r); j != nil {
+		writeJSON(w, http.StatusOK, j.snapshot())
+	}
+}
+
+func (s *Server) handleCancel(w http.ResponseWriter, r *http.Request) {
+	j := s.lookup(w, r)
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	state := j.view.State
+	j.canceled = state == StateQueued || state == StateRunning
+	if state == StateQueued {
+		j.finishLocked(StateCanceled, &events.Error{Code: events.CodeCanceled, Message: "job canceled"})
+	}
+	j.mu.Unlock()
+	j.cancel()
+
+	switch state {
+	case StateQueued:
+	case StateRunning:
+		<-j.done
+	default:
+		writeError(w, http.StatusConflict, "job already finished")
+		return
+	}
+	writeJSON(w, http.StatusOK, j.snapshot())
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	j := s.lookup(w, r)
+	if j == nil {
+		return
+	}
+
+	ch := make(chan events.Event, 16)
+	j.mu.Lock()
+	final := j.final
+	if final == nil {
+		j.subs[ch] = struct{}{}
+	}
+	j.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+
+	for final == nil {
+		select {
+		case <-r.Context().Done():
+			j.mu.Lock()
+			delete(j.subs, ch)
+			j.mu.Unlock()
+			return
+		case ev, ok := <-ch:
+			if !ok {
+				j.mu.Lock()
+				final = j.final
+				j.mu.Unlock()
+				continue
+			}
+			writeSSE(w, ev)
+			rc.Flush()
+		}
+	}
+	writeSSE(w, final)
+	rc.Flush()
+}
+
+func writeSSE(w io.Writer, ev events.Event) {
+	data, err := events.Marshal(ev)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", events.Type(ev), data)
+}
+
+func (s *Server) handleKeys(w http.ResponseWriter, r *http.Request) {
+	j := s.lookup(w, r)
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	state := j.view.State
+	j.mu.Unlock()
+	if state != StateFound {
+		writeError(w, http.StatusConflict, "job has no keys (state "+state+")")
+		return
+	}
+
+	files := make(map[string]string)
+	err := filepath.WalkDir(j.spec.Output, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.Base(path)] = base64.StdEncoding.EncodeToString(data)
+		return nil
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "reading keys: "+err.Error())
+		return
+	}
+	view := j.snapshot()
+	writeJSON(w, http.StatusOK, map[string]any{"id": view.ID, "address": view.Address, "files": files})
+}
+
+func (j *job) isFinished() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.final != nil
+}
+
+func (j *job) snapshot() JobView {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.view
+}
+
+func newID() (string, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("generating job id: %w", err)
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
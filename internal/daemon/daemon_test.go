@@ -0,0 +1,203 @@
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testToken = "secret"
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return newTestServerIn(t, t.TempDir())
+}
+
+func newTestServerIn(t *testing.T, dir string) *httptest.Server {
+	t.Helper()
+	return newTestServerWith(t, Config{OutDir: dir})
+}
+
+func newTestServerWith(t *testing.T, cfg Config) *httptest.Server {
+	t.Helper()
+	cfg.Token, cfg.Concurrency = testToken, 1
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s)
+	t.Cleanup(func() {
+		s.Close()
+		ts.Close()
+	})
+	return ts
+}
+
+func do(t *testing.T, ts *httptest.Server, method, path, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func decode(t *testing.T, resp *http.Response, v any) {
+	t.Helper()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRequiresToken(t *testing.T) {
+	ts := newTestServer(t)
+	resp, err := ts.Client().Get(ts.URL + "/v1/jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestSubmitFindAndFetchKeys(t *testing.T) {
+	ts := newTestServer(t)
+
+	resp := do(t, ts, "POST", "/v1/jobs", `{"network":"torv3","prefix":"a","cores":1}`)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("submit status = %d", resp.StatusCode)
+	}
+	var view JobView
+	decode(t, resp, &view)
+
+	// The event stream ends with the final event once the job is done.
+	resp = do(t, ts, "GET", "/v1/jobs/"+view.ID+"/events", "")
+	var last string
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		if ev, ok := strings.CutPrefix(sc.Text(), "event: "); ok {
+			last = ev
+		}
+	}
+	if last != "result" {
+		t.Fatalf("last event = %q, want result", last)
+	}
+
+	decode(t, do(t, ts, "GET", "/v1/jobs/"+view.ID, ""), &view)
+	if view.State != StateFound || !strings.HasPrefix(view.Address, "a") {
+		t.Fatalf("job = %+v", view)
+	}
+
+	var keys struct {
+		Files map[string]string `json:"files"`
+	}
+	decode(t, do(t, ts, "GET", "/v1/jobs/"+view.ID+"/keys", ""), &keys)
+	if _, ok := keys.Files["hs_ed25519_secret_key"]; !ok {
+		t.Errorf("keys missing secret key: %v", keys.Files)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Unreachable prefix so the job runs until canceled.
+	var view JobView
+	decode(t, do(t, ts, "POST", "/v1/jobs", `{"network":"i2p","prefix":"zzzzzzzzzzzz","cores":1}`), &view)
+	deadline := time.Now().Add(5 * time.Second)
+	for view.State != StateRunning && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		decode(t, do(t, ts, "GET", "/v1/jobs/"+view.ID, ""), &view)
+	}
+
+	resp := do(t, ts, "DELETE", "/v1/jobs/"+view.ID, "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("cancel status = %d", resp.StatusCode)
+	}
+	decode(t, resp, &view)
+	if view.State != StateCanceled {
+		t.Errorf("state = %q, want canceled", view.State)
+	}
+
+	if resp := do(t, ts, "DELETE", "/v1/jobs/"+view.ID, ""); resp.StatusCode != http.StatusConflict {
+		t.Errorf("second cancel status = %d, want 409", resp.StatusCode)
+	}
+	if resp := do(t, ts, "GET", "/v1/jobs/"+view.ID+"/keys", ""); resp.StatusCode != http.StatusConflict {
+		t.Errorf("keys status = %d, want 409", resp.StatusCode)
+	}
+}
+
+// TestCancelWritesNoKeys cancels jobs that find a match almost at once, so
+// DELETE races the job starting and finding; a job reported canceled must
+// not leave keys behind.
+func TestCancelWritesNoKeys(t *testing.T) {
+	dir := t.TempDir()
+	ts := newTestServerIn(t, dir)
+
+	for i := 0; i < 20; i++ {
+		var view JobView
+		decode(t, do(t, ts, "POST", "/v1/jobs", `{"network":"i2p","prefix":"a","cores":1}`), &view)
+		resp := do(t, ts, "DELETE", "/v1/jobs/"+view.ID, "")
+		if resp.StatusCode == http.StatusConflict {
+			continue // found before the cancel arrived
+		}
+		decode(t, resp, &view)
+		if view.State != StateCanceled {
+			t.Fatalf("state = %q, want canceled", view.State)
+		}
+		if _, err := os.Stat(filepath.Join(dir, view.ID+".dat")); err == nil {
+			t.Fatalf("canceled job %s saved keys", view.ID)
+		}
+	}
+}
+
+func TestSubmitRejectsInvalidJobs(t *testing.T) {
+	ts := newTestServer(t)
+	for _, body := range []string{
+		`{"prefix":"ab!"}`,
+		`{"prefix":"ab","output":"/tmp/x"}`,
+		`{"prefix":"ab","bogus":1}`,
+		`not json`,
+	} {
+		if resp := do(t, ts, "POST", "/v1/jobs", body); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, resp.StatusCode)
+		}
+	}
+}
+
+func TestForgetsOldFinishedJobs(t *testing.T) {
+	ts := newTestServerWith(t, Config{OutDir: t.TempDir(), KeepFinished: 1})
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		var view JobView
+		decode(t, do(t, ts, "POST", "/v1/jobs", `{"network":"torv3","prefix":"a","cores":1}`), &view)
+		deadline := time.Now().Add(5 * time.Second)
+		for view.State != StateFound && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+			decode(t, do(t, ts, "GET", "/v1/jobs/"+view.ID, ""), &view)
+		}
+		ids = append(ids, view.ID)
+	}
+
+	var list []JobView
+	decode(t, do(t, ts, "GET", "/v1/jobs", ""), &list)
+	if len(list) != 2 || list[0].ID != ids[1] || list[1].ID != ids[2] {
+		t.Errorf("jobs = %+v, want %s and %s", list, ids[1], ids[2])
+	}
+	if resp := do(t, ts, "GET", "/v1/jobs/"+ids[0], ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("forgotten job: status = %d, want 404", resp.StatusCode)
+	}
+}
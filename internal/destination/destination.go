@@ -1,10 +1,13 @@
 package destination
 
 import (
+	"bytes"
+	"crypto/ecdh"
 	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base32"
+	"encoding/base64"
 	"encoding/binary"
 	"fmt"
 	"os"
@@ -19,16 +22,24 @@ const (
 	SigningKeySize    = 128
 	Ed25519PubKeySize = 32
 	CertificateSize   = 7
-	DestinationSize   = EncryptionKeySize + SigningKeySize + CertificateSize // 391
+	DestinationSize   = EncryptionKeySize + SigningKeySize + CertificateSize   // 391
+	KeysFileSize      = DestinationSize + EncryptionKeySize + ed25519.SeedSize // 679, as written by SaveKeys for ElGamal
 
 	CertTypeKeyCert           = 5
 	CertPayloadLength         = 4
 	SigTypeEdDSASHA512Ed25519 = 7
 	CryptoTypeElGamal         = 0
+	CryptoTypeX25519          = 4 // ECIES-X25519-AEAD-Ratchet
+	X25519KeySize             = 32
+
+	X25519KeysFileSize = DestinationSize + X25519KeySize + ed25519.SeedSize // 455, as written by SaveKeys for X25519
 )
 
 var b32Encoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
 
+// b64Encoding is I2P's base64 variant, which uses '-' and '~' instead of '+' and '/'.
+var b64Encoding = base64.NewEncoding("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-~")
+
 // Destination represents an I2P destination with its associated keys.
 type Destination struct {
 	// The 391-byte destination (encryption pubkey + signing pubkey area + certificate)
@@ -37,20 +48,20 @@ type Destination struct {
 	// Ed25519 private key (64 bytes: seed + public key)
 	SigningPrivateKey ed25519.PrivateKey
 
-	// 256-byte encryption private key placeholder
+	// 256-byte encryption private key area. An X25519 destination keeps
+	// its 32-byte private key at the start and zero padding after it; an
+	// ElGamal one holds a random placeholder.
 	EncryptionPrivateKey [EncryptionKeySize]byte
 }
 
-// NewRandom generates a new random I2P destination with Ed25519 signing keys.
+// NewRandom generates a new random I2P destination with Ed25519 signing keys
+// and a random placeholder ElGamal encryption key, which is enough for a
+// destination that is only ever used through its leaseset keys.
 func NewRandom() (*Destination, error) {
-	d := &Destination{}
-
-	// Generate Ed25519 signing keypair
-	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	d, err := newEd25519(CryptoTypeElGamal)
 	if err != nil {
-		return nil, fmt.Errorf("generating Ed25519 key: %w", err)
+		return nil, err
 	}
-	d.SigningPrivateKey = priv
 
 	// Fill encryption public key with random bytes (ElGamal placeholder)
 	if _, err := rand.Read(d.Raw[:EncryptionKeySize]); err != nil {
@@ -61,6 +72,43 @@ func NewRandom() (*Destination, error) {
 	if _, err := rand.Read(d.EncryptionPrivateKey[:]); err != nil {
 		return nil, fmt.Errorf("generating encryption private key: %w", err)
 	}
+	return d, nil
+}
+
+// NewX25519 generates a new random I2P destination with Ed25519 signing keys
+// and a genuine X25519 encryption key (crypto type 4), as used by current
+// routers. The public key takes the first 32 bytes of the encryption key
+// area and the rest is random padding.
+func NewX25519() (*Destination, error) {
+	d, err := newEd25519(CryptoTypeX25519)
+	if err != nil {
+		return nil, err
+	}
+
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generating X25519 key: %w", err)
+	}
+	copy(d.Raw[:X25519KeySize], priv.PublicKey().Bytes())
+	copy(d.EncryptionPrivateKey[:], priv.Bytes())
+
+	if _, err := rand.Read(d.Raw[X25519KeySize:EncryptionKeySize]); err != nil {
+		return nil, fmt.Errorf("generating encryption key padding: %w", err)
+	}
+	return d, nil
+}
+
+// newEd25519 returns a destination with a fresh Ed25519 signing key and a
+// key certificate for cryptoType, leaving the encryption key to the caller.
+func newEd25519(cryptoType uint16) (*Destination, error) {
+	d := &Destination{}
+
+	// Generate Ed25519 signing keypair
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generating Ed25519 key: %w", err)
+	}
+	d.SigningPrivateKey = priv
 
 	// Signing public key area: 96 bytes zero padding + 32 bytes Ed25519 public key
 	// Zero padding is already zero from array initialization
@@ -76,12 +124,42 @@ func NewRandom() (*Destination, error) {
 	d.Raw[certOffset+3] = 0
 	d.Raw[certOffset+4] = SigTypeEdDSASHA512Ed25519
 	// Crypto type as big-endian uint16
-	d.Raw[certOffset+5] = 0
-	d.Raw[certOffset+6] = CryptoTypeElGamal
+	binary.BigEndian.PutUint16(d.Raw[certOffset+5:], cryptoType)
 
 	return d, nil
 }
 
+// CryptoType returns the encryption key type named by the key certificate.
+func (d *Destination) CryptoType() uint16 {
+	return binary.BigEndian.Uint16(d.Raw[EncryptionKeySize+SigningKeySize+5:])
+}
+
+// EncryptionPublicKey returns the encryption public key embedded in the
+// destination: the first 32 bytes of the area for X25519, all 256 for
+// ElGamal.
+func (d *Destination) EncryptionPublicKey() []byte {
+	if d.CryptoType() == CryptoTypeX25519 {
+		return d.Raw[:X25519KeySize]
+	}
+	return d.Raw[:EncryptionKeySize]
+}
+
+// CheckX25519 reports whether an X25519 destination's private key derives
+// its public key, which an ElGamal placeholder never does.
+func (d *Destination) CheckX25519() error {
+	if d.CryptoType() != CryptoTypeX25519 {
+		return fmt.Errorf("crypto type is %d, not X25519", d.CryptoType())
+	}
+	priv, err := ecdh.X25519().NewPrivateKey(d.EncryptionPrivateKey[:X25519KeySize])
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(priv.PublicKey().Bytes(), d.Raw[:X25519KeySize]) {
+		return fmt.Errorf("X25519 private key does not match the encryption public key in the destination")
+	}
+	return nil
+}
+
 // B32Address returns the 52-character base32 address (without .b32.i2p suffix).
 func (d *Destination) B32Address() string {
 	hash := sha256.Sum256(d.Raw[:])
@@ -94,53 +172,171 @@ func (d *Destination) HasB32Prefix(prefix string) bool {
 	return base32check.HasPrefixLowerNoPad(hash[:], prefix)
 }
 
+// HasB32PrefixMask is HasB32Prefix for a compiled prefix.
+func (d *Destination) HasB32PrefixMask(prefix *base32check.PrefixMask) bool {
+	hash := sha256.Sum256(d.Raw[:])
+	return prefix.Match(hash[:])
+}
+
+// B32PrefixMaskLen returns how many leading characters of the destination's
+// base32 address match the compiled prefix.
+func (d *Destination) B32PrefixMaskLen(prefix *base32check.PrefixMask) int {
+	hash := sha256.Sum256(d.Raw[:])
+	return prefix.MatchLen(hash[:])
+}
+
+// MatchesB32 reports whether the destination's base32 address matches the
+// prefix pattern and ends with suffix, hashing only once.
+func (d *Destination) MatchesB32(prefix base32check.Pattern, suffix string) bool {
+	hash := sha256.Sum256(d.Raw[:])
+	return prefix.Match(hash[:]) && base32check.HasSuffixLowerNoPad(hash[:], suffix)
+}
+
+// MatchB32Len returns how many leading characters of the destination's
+// base32 address match the prefix pattern, and whether the address matches
+// it in full and ends with suffix, hashing only once.
+func (d *Destination) MatchB32Len(prefix base32check.Pattern, suffix string) (int, bool) {
+	hash := sha256.Sum256(d.Raw[:])
+	n := prefix.MatchLen(hash[:])
+	return n, n == len(prefix) && base32check.HasSuffixLowerNoPad(hash[:], suffix)
+}
+
+// B32Hash returns the SHA-256 hash of the destination, whose base32
+// encoding is the address.
+func (d *Destination) B32Hash() [32]byte {
+	return sha256.Sum256(d.Raw[:])
+}
+
+// MatchB32Targets appends to out the index of every trie prefix the
+// destination's base32 address starts with, provided it also ends with
+// suffix, hashing only once.
+func (d *Destination) MatchB32Targets(t *base32check.PrefixTrie, suffix string, out []int) []int {
+	hash := sha256.Sum256(d.Raw[:])
+	if !base32check.HasSuffixLowerNoPad(hash[:], suffix) {
+		return out
+	}
+	return t.Match(hash[:], out)
+}
+
+// Base64 returns the destination in I2P's base64 encoding, as used in address books.
+func (d *Destination) Base64() string {
+	return b64Encoding.EncodeToString(d.Raw[:])
+}
+
+// ParseBase64 decodes a destination from I2P's base64 encoding. Only
+// destinations of the fixed 391-byte layout this tool produces are accepted.
+func ParseBase64(s string) (*Destination, error) {
+	raw, err := b64Encoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("decoding destination: %w", err)
+	}
+	if len(raw) != DestinationSize {
+		return nil, fmt.Errorf("destination must be %d bytes, got %d", DestinationSize, len(raw))
+	}
+	d := &Destination{}
+	copy(d.Raw[:], raw)
+	return d, nil
+}
+
 // FullB32Address returns the complete .b32.i2p address.
 func (d *Destination) FullB32Address() string {
 	return d.B32Address() + ".b32.i2p"
 }
 
-// MutateEncryptionKey embeds a counter into the encryption key area to produce
-// a different destination hash without regenerating the Ed25519 signing key.
-func (d *Destination) MutateEncryptionKey(counter uint64) {
-	binary.LittleEndian.PutUint64(d.Raw[0:8], counter)
+// CounterOffset returns where MutatePadding writes its counter: just past
+// the public key in the unused encryption key area of an X25519
+// destination, otherwise at the start of the signing key padding. Routers
+// treat both as opaque, so the keys stay valid.
+func (d *Destination) CounterOffset() int {
+	if d.CryptoType() == CryptoTypeX25519 {
+		return X25519KeySize
+	}
+	return EncryptionKeySize
+}
+
+// MutatePadding embeds a counter into the destination's padding (see
+// CounterOffset) to produce a different destination hash without touching
+// the encryption or signing keys.
+func (d *Destination) MutatePadding(counter uint64) {
+	off := d.CounterOffset()
+	binary.LittleEndian.PutUint64(d.Raw[off:off+8], counter)
 }
 
 // SaveKeys writes the destination and private keys to a file.
-// Format: destination (391) + encryption private key (256) + Ed25519 private seed (32) = 679 bytes
+// Format: destination (391) + encryption private key (256 for ElGamal, 32 for
+// X25519) + Ed25519 private seed (32) = 679 or 455 bytes
 func (d *Destination) SaveKeys(path string) error {
-	buf := make([]byte, 0, DestinationSize+EncryptionKeySize+ed25519.SeedSize)
+	return os.WriteFile(path, d.MarshalKeys(), 0600)
+}
+
+// KeysFileSizeFor returns the size of a key file whose certificate names
+// cryptoType: X25519KeysFileSize for X25519 and KeysFileSize otherwise.
+func KeysFileSizeFor(cryptoType uint16) int {
+	if cryptoType == CryptoTypeX25519 {
+		return X25519KeysFileSize
+	}
+	return KeysFileSize
+}
+
+// encryptionPrivateKeySize returns how much of EncryptionPrivateKey a key
+// file holds for d's crypto type.
+func (d *Destination) encryptionPrivateKeySize() int {
+	return KeysFileSizeFor(d.CryptoType()) - DestinationSize - ed25519.SeedSize
+}
+
+// MarshalKeys returns the key file contents written by SaveKeys. This is the
+// I2P private key file layout, also read by the Java router and i2pd: the
+// encryption private key takes the size its crypto type calls for.
+func (d *Destination) MarshalKeys() []byte {
+	buf := make([]byte, 0, KeysFileSizeFor(d.CryptoType()))
 	buf = append(buf, d.Raw[:]...)
-	buf = append(buf, d.EncryptionPrivateKey[:]...)
-	buf = append(buf, d.SigningPrivateKey.Seed()...)
-	return os.WriteFile(path, buf, 0600)
+	buf = append(buf, d.EncryptionPrivateKey[:d.encryptionPrivateKeySize()]...)
+	return append(buf, d.SigningPrivateKey.Seed()...)
+}
+
+// PrivateKeyBase64 returns the key file in I2P's base64 encoding, the private
+// key string used by SAM and tunnel configurations.
+func (d *Destination) PrivateKeyBase64() string {
+	return b64Encoding.EncodeToString(d.MarshalKeys())
 }
 
-// ValidatePrefix checks that a vanity prefix contains only valid base32 characters.
-func ValidatePrefix(prefix string) error {
-	if len(prefix) == 0 {
-		return fmt.Errorf("prefix cannot be empty")
+// ParsePrivateKeyBase64 decodes a private key string from PrivateKeyBase64.
+func ParsePrivateKeyBase64(s string) (*Destination, error) {
+	data, err := b64Encoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("decoding private key: %w", err)
 	}
-	if len(prefix) > 52 {
-		return fmt.Errorf("prefix cannot exceed 52 characters")
+	return ParseKeys(data)
+}
+
+// ParseKeys decodes the format written by SaveKeys. Only the size, which
+// follows from the crypto type in the key certificate, is checked; callers
+// that need to audit the contents should inspect the fields.
+func ParseKeys(data []byte) (*Destination, error) {
+	if len(data) < DestinationSize {
+		return nil, fmt.Errorf("key file must be at least %d bytes, got %d", DestinationSize, len(data))
 	}
-	prefix = strings.ToLower(prefix)
-	for i, c := range prefix {
-		if !((c >= 'a' && c <= 'z') || (c >= '2' && c <= '7')) {
-			return fmt.Errorf("invalid character '%c' at position %d (allowed: a-z, 2-7)", c, i)
-		}
+	d := &Destination{}
+	copy(d.Raw[:], data[:DestinationSize])
+	if want := KeysFileSizeFor(d.CryptoType()); len(data) != want {
+		return nil, fmt.Errorf("key file for crypto type %d must be %d bytes, got %d", d.CryptoType(), want, len(data))
 	}
-	return nil
+	n := d.encryptionPrivateKeySize()
+	copy(d.EncryptionPrivateKey[:], data[DestinationSize:DestinationSize+n])
+	d.SigningPrivateKey = ed25519.NewKeyFromSeed(data[DestinationSize+n:])
+	return d, nil
 }
 
-// EstimateAttempts returns the average number of attempts needed to find a prefix of the given length.
-func EstimateAttempts(prefixLen int) float64 {
-	if prefixLen <= 0 {
-		return 1
-	}
-	// 32^n / 2 average attempts
-	attempts := 1.0
-	for i := 0; i < prefixLen; i++ {
-		attempts *= 32
+// LoadKeys reads a key file written by SaveKeys.
+func LoadKeys(path string) (*Destination, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
 	}
-	return attempts / 2
+	return ParseKeys(data)
+}
+
+// SigningPublicKey returns the Ed25519 public key embedded in the destination.
+func (d *Destination) SigningPublicKey() ed25519.PublicKey {
+	return ed25519.PublicKey(d.Raw[EncryptionKeySize+SigningKeyPadding : EncryptionKeySize+SigningKeySize])
 }
@@ -0,0 +1,98 @@
+package destination
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"path/filepath"
+	"testing"
+)
+
+func TestMutatePaddingKeepsKeys(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		new  func() (*Destination, error)
+	}{
+		{"ElGamal", NewRandom},
+		{"X25519", NewX25519},
+	} {
+		d, err := tt.new()
+		if err != nil {
+			t.Fatal(err)
+		}
+		encPub := bytes.Clone(d.EncryptionPublicKey())
+		sigPub := bytes.Clone(d.SigningPublicKey())
+		before := d.B32Address()
+
+		d.MutatePadding(0x0102030405060708)
+		if d.B32Address() == before {
+			t.Errorf("%s: mutation did not change the address", tt.name)
+		}
+		if !bytes.Equal(d.EncryptionPublicKey(), encPub) || !bytes.Equal(d.SigningPublicKey(), sigPub) {
+			t.Errorf("%s: mutation changed a public key", tt.name)
+		}
+		if d.CryptoType() == CryptoTypeX25519 {
+			if err := d.CheckX25519(); err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+		}
+
+		// The saved file carries the mutated padding, so it loads back to
+		// the same address.
+		path := filepath.Join(t.TempDir(), "k.dat")
+		if err := d.SaveKeys(path); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadKeys(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loaded.FullB32Address() != d.FullB32Address() {
+			t.Errorf("%s: saved keys load as %s, want %s", tt.name, loaded.FullB32Address(), d.FullB32Address())
+		}
+	}
+}
+
+func TestX25519KeysRoundTrip(t *testing.T) {
+	d, err := NewX25519()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := d.MarshalKeys()
+	if len(data) != 455 {
+		t.Fatalf("key file is %d bytes, want 455", len(data))
+	}
+	priv, err := ecdh.X25519().NewPrivateKey(data[DestinationSize : DestinationSize+X25519KeySize])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(priv.PublicKey().Bytes(), d.EncryptionPublicKey()) {
+		t.Error("the key file's private key does not derive the encryption public key")
+	}
+
+	for _, tt := range []struct {
+		name  string
+		parse func() (*Destination, error)
+	}{
+		{"file", func() (*Destination, error) { return ParseKeys(data) }},
+		{"base64", func() (*Destination, error) { return ParsePrivateKeyBase64(d.PrivateKeyBase64()) }},
+	} {
+		loaded, err := tt.parse()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if loaded.FullB32Address() != d.FullB32Address() {
+			t.Errorf("%s: loads as %s, want %s", tt.name, loaded.FullB32Address(), d.FullB32Address())
+		}
+		if err := loaded.CheckX25519(); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if !bytes.Equal(loaded.SigningPrivateKey.Seed(), d.SigningPrivateKey.Seed()) {
+			t.Errorf("%s: signing seed changed", tt.name)
+		}
+	}
+
+	// The size must match the crypto type in the certificate.
+	if _, err := ParseKeys(append(data, make([]byte, KeysFileSize-X25519KeysFileSize)...)); err == nil {
+		t.Error("a 679-byte file with an X25519 certificate should be rejected")
+	}
+}
@@ -0,0 +1,365 @@
+// Package dfa compiles restricted regular expressions over the base32
+// alphabet into deterministic automata over 5-bit symbols, so a candidate
+// address can be matched straight from its hash bytes without encoding it.
+//
+// The syntax is Go's (RE2), limited to what makes sense for an address:
+// literals a-z and 2-7 (case-insensitive), '.', classes such as [a-e2-7] or
+// [^aeiou], groups, '|', and the quantifiers * + ? {n} {n,m}. A leading '^'
+// anchors the match at the first character and a trailing '$' at the last;
+// without '^' the expression may match anywhere in the address.
+package dfa
+
+import (
+	"fmt"
+	"math/bits"
+	"regexp/syntax"
+	"slices"
+	"strings"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/base32check"
+)
+
+// MaxStates bounds the size of a compiled automaton.
+const MaxStates = 4096
+
+const (
+	deadState  = 0
+	startState = 1
+)
+
+// DFA is a compiled expression. It is immutable and safe for concurrent use.
+type DFA struct {
+	next      []int32 // next[s<<5|v] is the state after symbol v in state s
+	accept    []bool
+	anchorEnd bool
+}
+
+// Compile parses expr and builds its automaton.
+func Compile(expr string) (*DFA, error) {
+	re, err := syntax.Parse(expr, syntax.Perl|syntax.FoldCase)
+	if err != nil {
+		return nil, err
+	}
+	re = re.Simplify()
+
+	anchorStart, anchorEnd := stripAnchors(re)
+	var n nfa
+	start := n.newState()
+	from := start
+	if !anchorStart {
+		// Unanchored: any number of characters may come first.
+		n.addEdge(start, base32check.AnyChar, start)
+		from = n.newState()
+		n.addEps(start, from)
+	}
+	final, err := n.build(re, from)
+	if err != nil {
+		return nil, err
+	}
+	return n.determinize(start, final, anchorEnd)
+}
+
+// MustCompile is like Compile but panics if expr is invalid.
+func MustCompile(expr string) *DFA {
+	d, err := Compile(expr)
+	if err != nil {
+		panic("dfa: " + err.Error())
+	}
+	return d
+}
+
+// stripAnchors removes a leading ^ and trailing $ from re, leaving any others
+// in place for build to reject.
+func stripAnchors(re *syntax.Regexp) (start, end bool) {
+	switch re.Op {
+	case syntax.OpBeginText:
+		re.Op = syntax.OpEmptyMatch
+		return true, false
+	case syntax.OpEndText:
+		re.Op = syntax.OpEmptyMatch
+		return false, true
+	case syntax.OpConcat:
+		if len(re.Sub) > 0 && re.Sub[0].Op == syntax.OpBeginText {
+			re.Sub, start = re.Sub[1:], true
+		}
+		if k := len(re.Sub) - 1; k >= 0 && re.Sub[k].Op == syntax.OpEndText {
+			re.Sub, end = re.Sub[:k], true
+		}
+	}
+	return start, end
+}
+
+// Match reports whether the lowercase base32 encoding of data matches.
+func (d *DFA) Match(data []byte) bool {
+	n := (len(data)*8 + 4) / 5
+	s := int32(startState)
+	if d.accept[s] && !d.anchorEnd {
+		return true
+	}
+	for i := 0; i < n; i++ {
+		s = d.next[s<<5|int32(base32check.Symbol(data, i))]
+		if s == deadState {
+			return false
+		}
+		if d.accept[s] && !d.anchorEnd {
+			return true
+		}
+	}
+	return d.accept[s]
+}
+
+// Probability returns the chance that a random address of n characters
+// matches, where the character at position i is uniformly distributed over
+// the set symbols(i) (bit v for base32check.Alphabet[v]).
+func (d *DFA) Probability(symbols func(pos int) uint32, n int) float64 {
+	dist := make([]float64, len(d.accept))
+	next := make([]float64, len(d.accept))
+	dist[startState] = 1
+	matched := 0.0
+	for i := 0; i < n; i++ {
+		if !d.anchorEnd {
+			matched += d.takeAccepted(dist)
+		}
+		set := symbols(i)
+		share := 1 / float64(bits.OnesCount32(set))
+		clear(next)
+		for s, p := range dist {
+			if p == 0 || s == deadState {
+				continue
+			}
+			for m := set; m != 0; m &= m - 1 {
+				next[d.next[s<<5|bits.TrailingZeros32(m)]] += p * share
+			}
+		}
+		dist, next = next, dist
+	}
+	return matched + d.takeAccepted(dist)
+}
+
+// takeAccepted zeroes the accepting states of dist and returns their total.
+func (d *DFA) takeAccepted(dist []float64) float64 {
+	total := 0.0
+	for s, p := range dist {
+		if d.accept[s] {
+			total += p
+			dist[s] = 0
+		}
+	}
+	return total
+}
+
+// States returns the number of states, including the dead state.
+func (d *DFA) States() int { return len(d.accept) }
+
+// Start returns the state matching begins in, for callers stepping the
+// automaton themselves. State 0 is dead: no further input leads to a match.
+func (d *DFA) Start() int32 { return startState }
+
+// Step returns the state after base32 value v in state s.
+func (d *DFA) Step(s int32, v byte) int32 { return d.next[s<<5|int32(v)] }
+
+// Accepts reports whether s is an accepting state.
+func (d *DFA) Accepts(s int32) bool { return d.accept[s] }
+
+// AnchoredEnd reports whether the expression ends in '$', so only the state
+// after the last character decides the match. Otherwise entering an
+// accepting state anywhere is a match.
+func (d *DFA) AnchoredEnd() bool { return d.anchorEnd }
+
+// nfa is a Thompson automaton whose edges carry sets of base32 values.
+type nfa struct {
+	edges [][]edge
+	eps   [][]int
+}
+
+type edge struct {
+	set uint32
+	to  int
+}
+
+func (n *nfa) newState() int {
+	n.edges = append(n.edges, nil)
+	n.eps = append(n.eps, nil)
+	return len(n.edges) - 1
+}
+
+func (n *nfa) addEdge(from int, set uint32, to int) {
+	n.edges[from] = append(n.edges[from], edge{set, to})
+}
+
+func (n *nfa) addEps(from, to int) {
+	n.eps[from] = append(n.eps[from], to)
+}
+
+// build adds re to the automaton starting at state from and returns the
+// state reached after it.
+func (n *nfa) build(re *syntax.Regexp, from int) (int, error) {
+	switch re.Op {
+	case syntax.OpEmptyMatch:
+		return from, nil
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			set := symbolSet(r)
+			if set == 0 {
+				return 0, fmt.Errorf("character %q is not in the base32 alphabet (a-z, 2-7)", r)
+			}
+			to := n.newState()
+			n.addEdge(from, set, to)
+			from = to
+		}
+		return from, nil
+	case syntax.OpCharClass:
+		set := classSet(re.Rune)
+		if set == 0 {
+			return 0, fmt.Errorf("class %s matches no base32 character", re)
+		}
+		to := n.newState()
+		n.addEdge(from, set, to)
+		return to, nil
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		to := n.newState()
+		n.addEdge(from, base32check.AnyChar, to)
+		return to, nil
+	case syntax.OpCapture:
+		return n.build(re.Sub[0], from)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			var err error
+			if from, err = n.build(sub, from); err != nil {
+				return 0, err
+			}
+		}
+		return from, nil
+	case syntax.OpAlternate:
+		end := n.newState()
+		for _, sub := range re.Sub {
+			s := n.newState()
+			n.addEps(from, s)
+			e, err := n.build(sub, s)
+			if err != nil {
+				return 0, err
+			}
+			n.addEps(e, end)
+		}
+		return end, nil
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest:
+		loop, end := n.newState(), n.newState()
+		n.addEps(from, loop)
+		e, err := n.build(re.Sub[0], loop)
+		if err != nil {
+			return 0, err
+		}
+		n.addEps(e, end)
+		if re.Op != syntax.OpPlus {
+			n.addEps(loop, end)
+		}
+		if re.Op != syntax.OpQuest {
+			n.addEps(e, loop)
+		}
+		return end, nil
+	case syntax.OpBeginText, syntax.OpBeginLine:
+		return 0, fmt.Errorf("'^' is only supported at the start of the expression")
+	case syntax.OpEndText, syntax.OpEndLine:
+		return 0, fmt.Errorf("'$' is only supported at the end of the expression")
+	case syntax.OpNoMatch:
+		return 0, fmt.Errorf("expression can never match")
+	}
+	return 0, fmt.Errorf("unsupported construct %s", re)
+}
+
+// determinize runs the subset construction. State sets are keyed by their
+// sorted member list.
+func (n *nfa) determinize(start, final int, anchorEnd bool) (*DFA, error) {
+	d := &DFA{anchorEnd: anchorEnd}
+	index := map[string]int32{}
+	var sets [][]int
+
+	add := func(set []int) int32 {
+		key := setKey(set)
+		if s, ok := index[key]; ok {
+			return s
+		}
+		s := int32(len(sets))
+		index[key] = s
+		sets = append(sets, set)
+		d.accept = append(d.accept, slices.Contains(set, final))
+		d.next = append(d.next, make([]int32, 32)...)
+		return s
+	}
+	add(nil) // deadState
+	add(n.closure([]int{start}))
+
+	for s := startState; s < len(sets); s++ {
+		if len(sets) > MaxStates {
+			return nil, fmt.Errorf("expression is too complex (more than %d states)", MaxStates)
+		}
+		for v := 0; v < 32; v++ {
+			var moved []int
+			for _, q := range sets[s] {
+				for _, e := range n.edges[q] {
+					if e.set&(1<<v) != 0 && !slices.Contains(moved, e.to) {
+						moved = append(moved, e.to)
+					}
+				}
+			}
+			if len(moved) == 0 {
+				continue // stays deadState
+			}
+			d.next[s<<5|v] = add(n.closure(moved))
+		}
+	}
+	return d, nil
+}
+
+// closure returns the sorted epsilon closure of states.
+func (n *nfa) closure(states []int) []int {
+	seen := make(map[int]bool)
+	stack := slices.Clone(states)
+	var out []int
+	for len(stack) > 0 {
+		q := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[q] {
+			continue
+		}
+		seen[q] = true
+		out = append(out, q)
+		stack = append(stack, n.eps[q]...)
+	}
+	slices.Sort(out)
+	return out
+}
+
+func setKey(set []int) string {
+	var b strings.Builder
+	for _, q := range set {
+		fmt.Fprintf(&b, "%d,", q)
+	}
+	return b.String()
+}
+
+// symbolSet returns the set bit for rune r in either case, or 0.
+func symbolSet(r rune) uint32 {
+	if r >= 'A' && r <= 'Z' {
+		r += 'a' - 'A'
+	}
+	if i := strings.IndexRune(base32check.Alphabet, r); i >= 0 {
+		return 1 << i
+	}
+	return 0
+}
+
+// classSet returns the base32 values in a class given as rune ranges. The
+// parser has already folded case into the ranges.
+func classSet(ranges []rune) uint32 {
+	var set uint32
+	for i, c := range base32check.Alphabet {
+		for j := 0; j+1 < len(ranges); j += 2 {
+			if ranges[j] <= c && c <= ranges[j+1] {
+				set |= 1 << i
+				break
+			}
+		}
+	}
+	return set
+}
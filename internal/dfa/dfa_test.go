@@ -0,0 +1,109 @@
+package dfa
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"math"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/base32check"
+)
+
+var encoding = base32.NewEncoding(base32check.Alphabet).WithPadding(base32.NoPadding)
+
+func TestMatchAgreesWithRegexp(t *testing.T) {
+	exprs := []string{
+		"^(my|our)shop[2-7]",
+		"^a",
+		"^[a-h]",
+		"^..[^aeiou]",
+		"a$",
+		"^[a-p]*b",
+		"(ab|ba)+",
+		"^(a|b){2,3}c?",
+		"q[2-7]{2}$",
+		"zz",
+		"^A[B-D]",
+	}
+	data := make([]byte, 32)
+	for _, expr := range exprs {
+		d, err := Compile(expr)
+		if err != nil {
+			t.Fatalf("Compile(%q): %v", expr, err)
+		}
+		re := regexp.MustCompile("(?i)" + expr)
+		for n := 0; n < 2000; n++ {
+			if _, err := rand.Read(data); err != nil {
+				t.Fatal(err)
+			}
+			s := encoding.EncodeToString(data)
+			if got, want := d.Match(data), re.MatchString(s); got != want {
+				t.Fatalf("%q on %s: Match = %v, regexp says %v", expr, s, got, want)
+			}
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		expr, wantErr string
+	}{
+		{"a1", "not in the base32 alphabet"},
+		{"[0189]", "matches no base32 character"},
+		{"a^b", "'^' is only supported at the start"},
+		{"a$b", "'$' is only supported at the end"},
+		{`\bab`, "unsupported"},
+		{"(ab", "missing closing )"},
+		{"(a|b)*a[a-z]{14}", "too complex"},
+	}
+	for _, tt := range tests {
+		_, err := Compile(tt.expr)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("Compile(%q): unexpected error %v", tt.expr, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("Compile(%q): error %v, want one containing %q", tt.expr, err, tt.wantErr)
+		}
+	}
+}
+
+func TestProbability(t *testing.T) {
+	uniform := func(int) uint32 { return base32check.AnyChar }
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{"^abc", 1.0 / (32 * 32 * 32)},
+		{"^(my|our)", 1.0/(32*32) + 1.0/(32*32*32)},
+		{"^[2-7]", 6.0 / 32},
+		{"a$", 1.0 / 32},
+		{"^.*", 1},
+		// "a" anywhere in 4 characters: 1 - (31/32)^4.
+		{"a", 1 - math.Pow(31.0/32, 4)},
+	}
+	for _, tt := range tests {
+		got := MustCompile(tt.expr).Probability(uniform, 4)
+		if math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("Probability(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	// The last position only allows 'a' and 'q'.
+	restricted := func(pos int) uint32 {
+		if pos == 3 {
+			return 1<<0 | 1<<16
+		}
+		return base32check.AnyChar
+	}
+	if got := MustCompile("b$").Probability(restricted, 4); got != 0 {
+		t.Errorf("impossible end: got %v, want 0", got)
+	}
+	if got := MustCompile("q$").Probability(restricted, 4); got != 0.5 {
+		t.Errorf("\"q$\": got %v, want 0.5", got)
+	}
+	if got := MustCompile("^.....").Probability(uniform, 4); got != 0 {
+		t.Errorf("too long: got %v, want 0", got)
+	}
+}
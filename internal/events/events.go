@@ -0,0 +1,174 @@
+// Package events defines the machine-readable event stream emitted by the
+// headless commands when run with -format ndjson.
+//
+// The stream is newline-delimited JSON: one object per line, written to
+// stdout. Every object carries the common header fields
+//
+//	schema  number  stream schema version (currently 1)
+//	type    string  "start", "stats", "result" or "error"
+//	time    string  RFC 3339 UTC timestamp of the event
+//
+// followed by the fields of its type:
+//
+//	start   network, prefix, prefixes, suffix, regex, contains and expr (omitted if unset), cores, gpu, estimated_attempts
+//	stats   checked, keys_per_sec, elapsed_sec, eta_sec (null until a rate is known),
+//	        best_address and best_len (omitted until a candidate matches part of the prefix)
+//	result  network, address, target (omitted if unset), attempts, duration_sec, saved_paths,
+//	        score (readability, only set by a -top search)
+//	error   code, message
+//
+// A search for several prefixes at once lists them in "prefixes", leaves
+// "prefix" empty and emits one result per prefix, naming it in "target".
+//
+// Error codes are listed as the Code* constants. Within a schema version
+// fields are only ever added; removing or redefining a field bumps
+// SchemaVersion, so consumers should check it and ignore unknown fields.
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/generator"
+)
+
+// SchemaVersion is the version of the event schema described in the package doc.
+const SchemaVersion = 1
+
+// Error codes carried by Error events.
+const (
+	CodeInvalidArgument = "invalid_argument" // bad flags, network or prefix
+	CodeNotFound        = "not_found"        // search ended (e.g. timeout) without a match
+	CodeInterrupted     = "interrupted"      // stopped by a signal
+	CodeSaveFailed      = "save_failed"      // match found but its keys could not be written
+	CodeCanceled        = "canceled"         // job cancelled through the daemon API
+	CodeInternal        = "internal"         // any other failure
+)
+
+// Event is implemented by every event type in this package.
+type Event interface {
+	header() *Header
+	eventType() string
+}
+
+// Header holds the fields common to all events. Writer fills it in.
+type Header struct {
+	Schema int       `json:"schema"`
+	Type   string    `json:"type"`
+	Time   time.Time `json:"time"`
+}
+
+func (h *Header) header() *Header { return h }
+
+// Start is emitted once before the search begins.
+type Start struct {
+	Header
+	Network           string   `json:"network"`
+	Prefix            string   `json:"prefix"`
+	Prefixes          []string `json:"prefixes,omitempty"`
+	Suffix            string   `json:"suffix,omitempty"`
+	Regex             string   `json:"regex,omitempty"`
+	Contains          string   `json:"contains,omitempty"`
+	Expr              string   `json:"expr,omitempty"`
+	Cores             int      `json:"cores"`
+	GPU               bool     `json:"gpu"`
+	EstimatedAttempts float64  `json:"estimated_attempts"`
+}
+
+// Stats is emitted for every generator.Stats tick.
+type Stats struct {
+	Header
+	Checked    uint64   `json:"checked"`
+	KeysPerSec float64  `json:"keys_per_sec"`
+	ElapsedSec float64  `json:"elapsed_sec"`
+	ETASec     *float64 `json:"eta_sec"`
+	// BestAddress is the candidate matching the most leading prefix
+	// characters so far, BestLen of them.
+	BestAddress string `json:"best_address,omitempty"`
+	BestLen     int    `json:"best_len,omitempty"`
+}
+
+// Result is emitted when a match has been found and saved.
+type Result struct {
+	Header
+	Network     string   `json:"network"`
+	Address     string   `json:"address"`
+	Target      string   `json:"target,omitempty"`
+	Attempts    uint64   `json:"attempts"`
+	DurationSec float64  `json:"duration_sec"`
+	SavedPaths  []string `json:"saved_paths"`
+	Score       *float64 `json:"score,omitempty"`
+}
+
+// Error is emitted when the command fails; it is always the last event.
+type Error struct {
+	Header
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (Start) eventType() string  { return "start" }
+func (Stats) eventType() string  { return "stats" }
+func (Result) eventType() string { return "result" }
+func (Error) eventType() string  { return "error" }
+
+// NewStats converts a generator.Stats tick into a Stats event. The ETA is
+// derived from estimatedAttempts and the current rate.
+func NewStats(st generator.Stats, estimatedAttempts float64) *Stats {
+	ev := &Stats{
+		Checked:    st.Checked,
+		KeysPerSec: st.KeysPerSec,
+		ElapsedSec: st.Elapsed.Seconds(),
+	}
+	if st.Best != nil {
+		ev.BestAddress, ev.BestLen = st.Best.Address, st.BestLen
+	}
+	if st.KeysPerSec > 0 {
+		remaining := estimatedAttempts - float64(st.Checked)
+		if remaining < 0 {
+			remaining = 0
+		}
+		eta := remaining / st.KeysPerSec
+		ev.ETASec = &eta
+	}
+	return ev
+}
+
+// Writer serializes events as NDJSON. It is safe for concurrent use.
+type Writer struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	now func() time.Time
+}
+
+// NewWriter returns a Writer that emits events to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{enc: json.NewEncoder(w), now: time.Now}
+}
+
+// Emit fills in the event header and writes the event as a single line.
+func (w *Writer) Emit(e Event) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	stamp(e, w.now())
+	return w.enc.Encode(e)
+}
+
+// Marshal fills in the event header and returns its JSON encoding, for
+// transports other than NDJSON such as server-sent events.
+func Marshal(e Event) ([]byte, error) {
+	stamp(e, time.Now())
+	return json.Marshal(e)
+}
+
+// Type returns the value of the "type" field for e.
+func Type(e Event) string { return e.eventType() }
+
+func stamp(e Event, now time.Time) {
+	h := e.header()
+	h.Schema = SchemaVersion
+	h.Type = e.eventType()
+	h.Time = now.UTC()
+}
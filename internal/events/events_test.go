@@ -0,0 +1,61 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/generator"
+)
+
+func TestWriterEmitsOneObjectPerLine(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	w.Emit(&Start{Network: "i2p", Prefix: "abc", Cores: 4, EstimatedAttempts: 16384})
+	w.Emit(NewStats(generator.Stats{Checked: 10}, 100))
+	w.Emit(&Result{Network: "i2p", Address: "abc.b32.i2p", SavedPaths: []string{"x.dat"}})
+	w.Emit(&Error{Code: CodeNotFound, Message: "nope"})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	wantTypes := []string{"start", "stats", "result", "error"}
+	if len(lines) != len(wantTypes) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(wantTypes), buf.String())
+	}
+	for i, line := range lines {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("line %d is not JSON: %v", i, err)
+		}
+		if m["schema"] != float64(SchemaVersion) {
+			t.Errorf("line %d: schema = %v", i, m["schema"])
+		}
+		if m["type"] != wantTypes[i] {
+			t.Errorf("line %d: type = %v, want %s", i, m["type"], wantTypes[i])
+		}
+		if m["time"] != "2026-01-02T03:04:05Z" {
+			t.Errorf("line %d: time = %v", i, m["time"])
+		}
+	}
+	if !strings.Contains(lines[1], `"eta_sec":null`) {
+		t.Errorf("stats without a rate should have a null ETA: %s", lines[1])
+	}
+}
+
+func TestNewStatsETA(t *testing.T) {
+	ev := NewStats(generator.Stats{Checked: 100, KeysPerSec: 50, Elapsed: 2 * time.Second}, 600)
+	if ev.ETASec == nil || *ev.ETASec != 10 {
+		t.Fatalf("ETA = %v, want 10", ev.ETASec)
+	}
+	if ev.ElapsedSec != 2 {
+		t.Errorf("elapsed = %v, want 2", ev.ElapsedSec)
+	}
+
+	ev = NewStats(generator.Stats{Checked: 1000, KeysPerSec: 50}, 600)
+	if ev.ETASec == nil || *ev.ETASec != 0 {
+		t.Errorf("ETA past the estimate should clamp to 0, got %v", ev.ETASec)
+	}
+}
@@ -0,0 +1,230 @@
+package expr
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/address"
+	"github.com/go-i2p/i2p-vanitygen/internal/dfa"
+)
+
+// maxJointStates bounds the joint state space of an exact probability. An
+// expression that needs more is estimated as if its conditions were
+// independent.
+const maxJointStates = 1 << 12
+
+// leaf is a function call. Besides matching, each leaf runs as a small
+// automaton over the characters of an address, so the probability of any
+// combination of leaves can be computed exactly by walking all of them in
+// step (see probability).
+type leaf interface {
+	node
+	base() *leafBase
+	// init returns the state before the first character.
+	init() int32
+	// step returns the state after base32 value v at position pos of an
+	// address of chars characters.
+	step(s int32, pos, chars int, v byte) int32
+	// accepts reports whether the leaf holds for an address that ends in s.
+	accepts(s int32) bool
+}
+
+// An atNode is 0 while the pattern still matches and 1 once it failed.
+
+func (n *atNode) init() int32 { return 0 }
+
+func (n *atNode) step(s int32, pos, chars int, v byte) int32 {
+	start, _ := n.locate(chars)
+	if i := pos - start; s == 0 && i >= 0 && i < len(n.p) && n.p[i]&(1<<v) == 0 {
+		return 1
+	}
+	return s
+}
+
+func (n *atNode) accepts(s int32) bool { return s == 0 }
+
+// A containsNode or regexNode runs its automaton, with matched standing for
+// "already matched" in an expression that may match anywhere.
+const matched = -1
+
+func (n *containsNode) init() int32                          { return dfaInit(n.d) }
+func (n *containsNode) step(s int32, _, _ int, v byte) int32 { return dfaStep(n.d, s, v) }
+func (n *containsNode) accepts(s int32) bool                 { return dfaAccepts(n.d, s) }
+
+func (n *regexNode) init() int32                          { return dfaInit(n.d) }
+func (n *regexNode) step(s int32, _, _ int, v byte) int32 { return dfaStep(n.d, s, v) }
+func (n *regexNode) accepts(s int32) bool                 { return dfaAccepts(n.d, s) }
+
+func dfaInit(d *dfa.DFA) int32 {
+	if s := d.Start(); !d.Accepts(s) || d.AnchoredEnd() {
+		return s
+	}
+	return matched
+}
+
+func dfaStep(d *dfa.DFA, s int32, v byte) int32 {
+	if s == matched {
+		return matched
+	}
+	s = d.Step(s, v)
+	if d.Accepts(s) && !d.AnchoredEnd() {
+		return matched
+	}
+	return s
+}
+
+func dfaAccepts(d *dfa.DFA, s int32) bool { return s == matched || d.Accepts(s) }
+
+// A countNode counts up to n+1, past which every comparison is settled.
+
+func (n *countNode) init() int32 { return 0 }
+
+func (n *countNode) step(s int32, _, _ int, v byte) int32 {
+	if n.set&(1<<v) != 0 && int(s) <= n.n {
+		return s + 1
+	}
+	return s
+}
+
+func (n *countNode) accepts(s int32) bool { return n.compare(int(s)) }
+
+// Probability returns the chance that a random address of s satisfies e,
+// and whether it is exact. It is exact unless the conditions together need
+// more than maxJointStates states to track, in which case they are assumed
+// to be independent of each other.
+func (e *Expr) Probability(s address.Scheme) (float64, bool) {
+	if p, ok := probability(e.root.eval, e.leaves, s, maxJointStates); ok {
+		return p, true
+	}
+	return e.independent(e.root, s), false
+}
+
+// independent combines the exact probability of each leaf as if the leaves
+// were independent.
+func (e *Expr) independent(n node, s address.Scheme) float64 {
+	switch n := n.(type) {
+	case *andNode:
+		p := 1.0
+		for _, sub := range n.subs {
+			p *= e.independent(sub, s)
+		}
+		return p
+	case *orNode:
+		q := 1.0
+		for _, sub := range n.subs {
+			q *= 1 - e.independent(sub, s)
+		}
+		return 1 - q
+	case *notNode:
+		return 1 - e.independent(n.sub, s)
+	}
+	return leafProbability(n.(leaf), s)
+}
+
+// leafProbability returns the exact probability of a single leaf, whose
+// states never exceed those of its automaton.
+func leafProbability(l leaf, s address.Scheme) float64 {
+	p, _ := probability(func(res []bool) bool { return res[0] }, []leaf{l}, s, math.MaxInt)
+	return p
+}
+
+// probability walks leaves over the characters of an address at once,
+// keeping the probability of each combination of leaf states, and sums the
+// combinations for which holds is true of the leaf outcomes at the end. It
+// gives up once there are more than limit combinations.
+func probability(holds func(res []bool) bool, leaves []leaf, s address.Scheme, limit int) (float64, bool) {
+	chars := s.MaxPrefixLen()
+	state := make([]int32, len(leaves))
+	stepped := make([]int32, len(leaves))
+	for i, l := range leaves {
+		state[i] = l.init()
+	}
+	dist := map[string]float64{encode(state): 1}
+	for pos := 0; pos < chars; pos++ {
+		set := s.Symbols(pos)
+		share := 1 / float64(bits.OnesCount32(set))
+		next := make(map[string]float64, len(dist))
+		for key, p := range dist {
+			decode(key, state)
+			for m := set; m != 0; m &= m - 1 {
+				v := byte(bits.TrailingZeros32(m))
+				for i, l := range leaves {
+					stepped[i] = l.step(state[i], pos, chars, v)
+				}
+				next[encode(stepped)] += p * share
+			}
+			if len(next) > limit {
+				return 0, false
+			}
+		}
+		dist = next
+	}
+
+	total := 0.0
+	res := make([]bool, len(leaves))
+	for key, p := range dist {
+		decode(key, state)
+		for i, l := range leaves {
+			res[i] = l.accepts(state[i])
+		}
+		if holds(res) {
+			total += p
+		}
+	}
+	return min(total, 1), true
+}
+
+func encode(state []int32) string {
+	buf := make([]byte, 4*len(state))
+	for i, s := range state {
+		binary.LittleEndian.PutUint32(buf[4*i:], uint32(s))
+	}
+	return string(buf)
+}
+
+func decode(key string, state []int32) {
+	for i := range state {
+		state[i] = int32(binary.LittleEndian.Uint32([]byte(key[4*i : 4*i+4])))
+	}
+}
+
+// Validate checks that e makes sense for addresses of s: every pattern
+// fits in the address and can match, and, where the probability is exact,
+// e can match some address but not every one.
+func (e *Expr) Validate(s address.Scheme) error {
+	chars := s.MaxPrefixLen()
+	for _, l := range e.leaves {
+		b := l.base()
+		if n, ok := l.(*atNode); ok {
+			if _, fits := n.locate(chars); !fits {
+				return &Error{b.col, fmt.Sprintf("%s does not fit in a %d-character %s address", b.src, chars, s.Suffix())}
+			}
+		}
+		if leafProbability(l, s) == 0 {
+			return &Error{b.col, fmt.Sprintf("%s can never hold for a%s address", b.src, s.Suffix())}
+		}
+	}
+	switch p, exact := e.Probability(s); {
+	case !exact:
+	case p == 0:
+		return fmt.Errorf("expression can never match a%s address", s.Suffix())
+	case p >= 1:
+		return fmt.Errorf("expression matches every address")
+	}
+	return nil
+}
+
+// EstimateAttempts returns the average number of attempts needed to find
+// an address of s satisfying e, like address.EstimateRegexAttempts.
+func (e *Expr) EstimateAttempts(s address.Scheme) float64 {
+	p, _ := e.Probability(s)
+	switch {
+	case p == 0:
+		return math.Inf(1)
+	case p >= 0.5:
+		return 1
+	}
+	return 1 / p / 2
+}
@@ -0,0 +1,257 @@
+// Package expr compiles match expressions that combine several conditions
+// on an address, such as
+//
+//	prefix("shop") && !contains("xxx") && count("7") < 3
+//
+// into a single predicate over a candidate's raw address bytes.
+//
+// The functions are:
+//
+//	prefix("p")       the address starts with pattern p (see base32check.ParsePattern)
+//	suffix("p")       the address ends with pattern p
+//	position(n, "p")  pattern p starts at character n, counting from 1
+//	contains("w")     word w occurs anywhere in the address
+//	regex("r")        the address matches r (see package dfa)
+//	count("cs") < n   the address has fewer than n characters from the set cs;
+//	                  the comparison, one of < <= > >= == !=, is required
+//
+// and they combine with ! (not), && (and), || (or) and parentheses, in the
+// usual order of precedence. Strings are double-quoted, without escapes.
+// Matching is case-insensitive throughout.
+package expr
+
+import (
+	"slices"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/base32check"
+	"github.com/go-i2p/i2p-vanitygen/internal/dfa"
+)
+
+// Expr is a compiled expression. It is immutable and safe for concurrent
+// use, and as an address.Matcher it can drive a generator search.
+type Expr struct {
+	src    string
+	root   node
+	leaves []leaf // in the order they appear in src
+}
+
+// Compile parses src and builds its predicate.
+func Compile(src string) (*Expr, error) {
+	root, leaves, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return &Expr{src: src, root: reorder(root), leaves: leaves}, nil
+}
+
+// MustCompile is like Compile but panics if src is invalid.
+func MustCompile(src string) *Expr {
+	e, err := Compile(src)
+	if err != nil {
+		panic("expr: " + err.Error())
+	}
+	return e
+}
+
+// String returns the source of e.
+func (e *Expr) String() string { return e.src }
+
+// Match reports whether the lowercase base32 encoding of data satisfies e.
+func (e *Expr) Match(data []byte) bool { return e.root.match(data) }
+
+// node is a compiled (sub)expression.
+type node interface {
+	match(data []byte) bool
+	// eval evaluates the node from the outcome of each leaf, by leaf id.
+	eval(res []bool) bool
+	// cost roughly ranks how expensive match is, so that the cheap checks
+	// of an && or || run first and the expensive ones rarely run at all.
+	cost() int
+}
+
+type andNode struct{ subs []node }
+
+func (n *andNode) match(data []byte) bool {
+	for _, s := range n.subs {
+		if !s.match(data) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n *andNode) eval(res []bool) bool {
+	for _, s := range n.subs {
+		if !s.eval(res) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n *andNode) cost() int { return sumCost(n.subs) }
+
+type orNode struct{ subs []node }
+
+func (n *orNode) match(data []byte) bool {
+	for _, s := range n.subs {
+		if s.match(data) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *orNode) eval(res []bool) bool {
+	for _, s := range n.subs {
+		if s.eval(res) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *orNode) cost() int { return sumCost(n.subs) }
+
+type notNode struct{ sub node }
+
+func (n *notNode) match(data []byte) bool { return !n.sub.match(data) }
+func (n *notNode) eval(res []bool) bool   { return !n.sub.eval(res) }
+func (n *notNode) cost() int              { return n.sub.cost() }
+
+func sumCost(subs []node) int {
+	total := 0
+	for _, s := range subs {
+		total += s.cost()
+	}
+	return total
+}
+
+// reorder sorts the operands of every && and || cheapest first. Matching
+// has no side effects, so the order only changes how soon it stops.
+func reorder(n node) node {
+	switch n := n.(type) {
+	case *andNode:
+		reorderSubs(n.subs)
+	case *orNode:
+		reorderSubs(n.subs)
+	case *notNode:
+		reorder(n.sub)
+	}
+	return n
+}
+
+func reorderSubs(subs []node) {
+	for _, s := range subs {
+		reorder(s)
+	}
+	slices.SortStableFunc(subs, func(a, b node) int { return a.cost() - b.cost() })
+}
+
+// leafBase holds what every function call shares.
+type leafBase struct {
+	id  int    // index into the leaf outcomes passed to eval
+	col int    // column of the call in the source, from 1
+	src string // the call as written
+}
+
+func (l *leafBase) eval(res []bool) bool { return res[l.id] }
+func (l *leafBase) base() *leafBase      { return l }
+
+// atNode checks a pattern at a fixed position: off characters from the
+// start, or -off from the end when off is negative.
+type atNode struct {
+	leafBase
+	p    base32check.Pattern
+	off  int
+	mask *base32check.PrefixMask // set for a literal prefix
+}
+
+func (n *atNode) match(data []byte) bool {
+	if n.mask != nil {
+		return n.mask.Match(data)
+	}
+	start, ok := n.locate((len(data)*8 + 4) / 5)
+	if !ok {
+		return false
+	}
+	for i, set := range n.p {
+		if set&(1<<base32check.Symbol(data, start+i)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// locate returns the first character the pattern covers in an address of
+// chars characters, and whether the pattern fits there.
+func (n *atNode) locate(chars int) (int, bool) {
+	start := n.off
+	if start < 0 {
+		start += chars
+	}
+	return start, start >= 0 && start+len(n.p) <= chars
+}
+
+func (n *atNode) cost() int {
+	if n.mask != nil {
+		return 1
+	}
+	return 2
+}
+
+// containsNode checks for a word anywhere in the address. Matching uses
+// the bit-window scan of base32check.Substring; the automaton serves the
+// probability estimate.
+type containsNode struct {
+	leafBase
+	word base32check.Substring
+	d    *dfa.DFA
+}
+
+func (n *containsNode) match(data []byte) bool { return n.word.In(data) }
+func (n *containsNode) cost() int              { return 4 }
+
+type regexNode struct {
+	leafBase
+	d *dfa.DFA
+}
+
+func (n *regexNode) match(data []byte) bool { return n.d.Match(data) }
+func (n *regexNode) cost() int              { return 8 }
+
+// countNode compares the number of characters from set against n.
+type countNode struct {
+	leafBase
+	set uint32
+	op  string
+	n   int
+}
+
+func (n *countNode) match(data []byte) bool {
+	count := 0
+	for i := 0; i < (len(data)*8+4)/5; i++ {
+		if n.set&(1<<base32check.Symbol(data, i)) != 0 {
+			count++
+		}
+	}
+	return n.compare(count)
+}
+
+func (n *countNode) compare(count int) bool {
+	switch n.op {
+	case "<":
+		return count < n.n
+	case "<=":
+		return count <= n.n
+	case ">":
+		return count > n.n
+	case ">=":
+		return count >= n.n
+	case "==":
+		return count == n.n
+	}
+	return count != n.n
+}
+
+func (n *countNode) cost() int { return 8 }
@@ -0,0 +1,173 @@
+package expr
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"math"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/address"
+	"github.com/go-i2p/i2p-vanitygen/internal/base32check"
+)
+
+var exprTests = []struct {
+	src  string
+	want func(addr string) bool
+}{
+	{`prefix("a")`, func(a string) bool { return strings.HasPrefix(a, "a") }},
+	{`prefix("ab") || suffix("q")`, func(a string) bool { return strings.HasPrefix(a, "ab") || strings.HasSuffix(a, "q") }},
+	{`!contains("a") && count("2-7") >= 10`, func(a string) bool {
+		return !strings.Contains(a, "a") && len(regexp.MustCompile(`[2-7]`).FindAllString(a, -1)) >= 10
+	}},
+	{`position(3, "[a-m]?z") && (regex("b.c") || !suffix("[aeiou]a"))`, func(a string) bool {
+		return regexp.MustCompile(`^..[a-m].z`).MatchString(a) &&
+			(regexp.MustCompile(`b.c`).MatchString(a) || !regexp.MustCompile(`[aeiou]a$`).MatchString(a))
+	}},
+	{`count("7") < 3 && count("Q") != 1`, func(a string) bool { return strings.Count(a, "7") < 3 && strings.Count(a, "q") != 1 }},
+	{`regex("^[a-m]+") && count("a") <= 1`, func(a string) bool {
+		return regexp.MustCompile(`^[a-m]`).MatchString(a) && strings.Count(a, "a") <= 1
+	}},
+}
+
+// TestMatch checks Match, and the leaf automata behind the probability
+// estimate, against a direct reading of the encoded address.
+func TestMatch(t *testing.T) {
+	encoding := base32.NewEncoding(base32check.Alphabet).WithPadding(base32.NoPadding)
+	data := make([]byte, 32)
+	for _, tt := range exprTests {
+		e := MustCompile(tt.src)
+		seen := map[bool]int{}
+		for n := 0; n < 3000; n++ {
+			if _, err := rand.Read(data); err != nil {
+				t.Fatal(err)
+			}
+			addr := encoding.EncodeToString(data)
+			want := tt.want(addr)
+			seen[want]++
+			if got := e.Match(data); got != want {
+				t.Fatalf("%s on %s: Match = %v, want %v", tt.src, addr, got, want)
+			}
+			if got := e.root.eval(walk(e.leaves, data)); got != want {
+				t.Fatalf("%s on %s: automata give %v, want %v", tt.src, addr, got, want)
+			}
+		}
+		if seen[true] == 0 || seen[false] == 0 {
+			t.Errorf("%s: outcomes %v, want both", tt.src, seen)
+		}
+	}
+}
+
+// walk runs each leaf's automaton over data and returns the outcomes.
+func walk(leaves []leaf, data []byte) []bool {
+	chars := (len(data)*8 + 4) / 5
+	res := make([]bool, len(leaves))
+	for i, l := range leaves {
+		s := l.init()
+		for pos := 0; pos < chars; pos++ {
+			s = l.step(s, pos, chars, base32check.Symbol(data, pos))
+		}
+		res[i] = l.accepts(s)
+	}
+	return res
+}
+
+func TestProbability(t *testing.T) {
+	s := address.I2PScheme{}
+	tests := []struct {
+		src  string
+		want float64
+	}{
+		{`prefix("a")`, 1.0 / 32},
+		{`prefix("a") || prefix("b")`, 2.0 / 32},
+		{`prefix("a") && !prefix("ab")`, 1.0 / 32 * 31 / 32},
+		{`suffix("q")`, 0.5},
+		{`position(2, "[a-h]") && suffix("q")`, 0.25 * 0.5},
+		// 51 free characters, then a or q.
+		{`count("a") == 0`, math.Pow(31.0/32, 51) / 2},
+		{`contains("shop")`, 1 / address.EstimateContainsAttempts(s, "shop") / 2},
+	}
+	for _, tt := range tests {
+		p, exact := MustCompile(tt.src).Probability(s)
+		if !exact || math.Abs(p-tt.want) > 1e-12 {
+			t.Errorf("%s: Probability = %v (exact %v), want %v", tt.src, p, exact, tt.want)
+		}
+	}
+
+	// Too many joint states: each regex tracks a window of its own.
+	e := MustCompile(`regex("a.....b") && regex("c.....d") && regex("e.....f")`)
+	p, exact := e.Probability(s)
+	if exact {
+		t.Error("large expression reported as exact")
+	}
+	if p <= 0 || p >= 1 {
+		t.Errorf("approximate probability %v", p)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		src, msg string
+		col      int
+	}{
+		{`prefix("a") &&`, "expected a condition", 15},
+		{`prefix("a"`, "expected ')'", 11},
+		{`prefix(a)`, "expected a quoted string", 8},
+		{`prefix("a1")`, "invalid character '1'", 8},
+		{`prefix("")`, "at least one character", 8},
+		{`prefx("a")`, `unknown function "prefx"`, 1},
+		{`count("7")`, "comparison after count", 11},
+		{`count("7") < "3"`, "expected a number", 14},
+		{`prefix("a") < 3`, "only count(...) can be compared", 13},
+		{`position(0, "a")`, "count from 1", 10},
+		{`regex("a(")`, "invalid regex", 7},
+		{`prefix("a") & prefix("b")`, "unexpected character '&'", 13},
+		{`prefix("a)`, "unterminated string", 8},
+		{`(prefix("a")`, "expected ')'", 13},
+		{`prefix("a") prefix("b")`, "unexpected 'prefix'", 13},
+	}
+	for _, tt := range tests {
+		_, err := Compile(tt.src)
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("%s: error %v, want an *Error", tt.src, err)
+			continue
+		}
+		if !strings.Contains(e.Msg, tt.msg) || e.Col != tt.col {
+			t.Errorf("%s: error %q at column %d, want %q at column %d", tt.src, e.Msg, e.Col, tt.msg, tt.col)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	s := address.I2PScheme{}
+	tests := map[string]string{
+		`prefix("a") || !prefix("a")`:      "matches every address",
+		`prefix("a") && prefix("b")`:       "can never match",
+		`suffix("b")`:                      `suffix("b") can never hold`,
+		`position(52, "ab")`:               "does not fit in a 52-character",
+		`prefix("a") && count("a") > 1000`: "can never hold",
+	}
+	for src, msg := range tests {
+		err := MustCompile(src).Validate(s)
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("%s: error %v, want %q", src, err, msg)
+		}
+	}
+	if err := MustCompile(`prefix("shop") && !contains("xxx") && count("7") < 3`).Validate(s); err != nil {
+		t.Errorf("valid expression: %v", err)
+	}
+}
+
+func TestCheapChecksFirst(t *testing.T) {
+	e := MustCompile(`count("7") < 3 && regex("ab") && prefix("x")`)
+	and := e.root.(*andNode)
+	if _, ok := and.subs[0].(*atNode); !ok {
+		t.Errorf("first check is %T, want the prefix", and.subs[0])
+	}
+	if got := e.leaves[0].base().src; got != `count("7") < 3` {
+		t.Errorf("first leaf source = %q", got)
+	}
+}
@@ -0,0 +1,371 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/base32check"
+	"github.com/go-i2p/i2p-vanitygen/internal/dfa"
+)
+
+// Error is a problem with an expression, at a column of its source
+// counting from 1.
+type Error struct {
+	Col int
+	Msg string
+}
+
+func (e *Error) Error() string { return fmt.Sprintf("column %d: %s", e.Col, e.Msg) }
+
+type tokenKind int
+
+const (
+	tkEOF tokenKind = iota
+	tkIdent
+	tkString
+	tkNumber
+	tkLParen
+	tkRParen
+	tkComma
+	tkNot
+	tkAnd
+	tkOr
+	tkCompare
+)
+
+type token struct {
+	kind tokenKind
+	text string // the token as written; a string's text is unquoted
+	pos  int    // byte offset in the source
+}
+
+// describe names t for error messages.
+func (t token) describe() string {
+	switch t.kind {
+	case tkEOF:
+		return "end of expression"
+	case tkString:
+		return strconv.Quote(t.text)
+	}
+	return "'" + t.text + "'"
+}
+
+func lex(src string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"':
+			end := strings.IndexByte(src[i+1:], '"')
+			if end < 0 {
+				return nil, &Error{i + 1, "unterminated string"}
+			}
+			toks = append(toks, token{tkString, src[i+1 : i+1+end], i})
+			i += end + 2
+		case isLetter(c):
+			j := i
+			for j < len(src) && (isLetter(src[j]) || isDigit(src[j])) {
+				j++
+			}
+			toks = append(toks, token{tkIdent, src[i:j], i})
+			i = j
+		case isDigit(c):
+			j := i
+			for j < len(src) && isDigit(src[j]) {
+				j++
+			}
+			toks = append(toks, token{tkNumber, src[i:j], i})
+			i = j
+		default:
+			kind, n := operator(src[i:])
+			if n == 0 {
+				return nil, &Error{i + 1, fmt.Sprintf("unexpected character %q", c)}
+			}
+			toks = append(toks, token{kind, src[i : i+n], i})
+			i += n
+		}
+	}
+	return append(toks, token{tkEOF, "", len(src)}), nil
+}
+
+// operator returns the punctuation token s starts with and its length, or
+// a length of 0.
+func operator(s string) (tokenKind, int) {
+	for _, op := range []string{"&&", "||", "<=", ">=", "==", "!="} {
+		if strings.HasPrefix(s, op) {
+			switch op {
+			case "&&":
+				return tkAnd, 2
+			case "||":
+				return tkOr, 2
+			}
+			return tkCompare, 2
+		}
+	}
+	switch s[0] {
+	case '(':
+		return tkLParen, 1
+	case ')':
+		return tkRParen, 1
+	case ',':
+		return tkComma, 1
+	case '!':
+		return tkNot, 1
+	case '<', '>':
+		return tkCompare, 1
+	}
+	return tkEOF, 0
+}
+
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+
+// parser is a recursive descent parser over the grammar
+//
+//	or    = and { "||" and }
+//	and   = unary { "&&" unary }
+//	unary = "!" unary | "(" or ")" | call
+//	call  = name "(" [ number "," ] string ")" [ compare number ]
+type parser struct {
+	toks   []token
+	pos    int
+	leaves []leaf
+}
+
+func parse(src string) (node, []leaf, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := &parser{toks: toks}
+	n, err := p.or()
+	if err != nil {
+		return nil, nil, err
+	}
+	if t := p.peek(); t.kind != tkEOF {
+		return nil, nil, p.errorf(t, "unexpected %s", t.describe())
+	}
+	return n, p.leaves, nil
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tkEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) expect(kind tokenKind, what string) (token, error) {
+	t := p.next()
+	if t.kind != kind {
+		return t, p.errorf(t, "expected %s, found %s", what, t.describe())
+	}
+	return t, nil
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return &Error{t.pos + 1, fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) or() (node, error) {
+	n, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	subs := []node{n}
+	for p.peek().kind == tkOr {
+		p.next()
+		if n, err = p.and(); err != nil {
+			return nil, err
+		}
+		subs = append(subs, n)
+	}
+	if len(subs) == 1 {
+		return subs[0], nil
+	}
+	return &orNode{subs}, nil
+}
+
+func (p *parser) and() (node, error) {
+	n, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	subs := []node{n}
+	for p.peek().kind == tkAnd {
+		p.next()
+		if n, err = p.unary(); err != nil {
+			return nil, err
+		}
+		subs = append(subs, n)
+	}
+	if len(subs) == 1 {
+		return subs[0], nil
+	}
+	return &andNode{subs}, nil
+}
+
+func (p *parser) unary() (node, error) {
+	switch t := p.peek(); t.kind {
+	case tkNot:
+		p.next()
+		n, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{n}, nil
+	case tkLParen:
+		p.next()
+		n, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tkRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case tkIdent:
+		return p.call()
+	default:
+		return nil, p.errorf(t, "expected a condition such as prefix(\"abc\"), found %s", t.describe())
+	}
+}
+
+func (p *parser) call() (node, error) {
+	name := p.next()
+	switch name.text {
+	case "prefix", "suffix", "position", "contains", "regex", "count":
+	default:
+		return nil, p.errorf(name, "unknown function %q (allowed: prefix, suffix, position, contains, regex, count)", name.text)
+	}
+	if _, err := p.expect(tkLParen, "'(' after "+name.text); err != nil {
+		return nil, err
+	}
+	pos := -1
+	if name.text == "position" {
+		t, err := p.expect(tkNumber, "a character position")
+		if err != nil {
+			return nil, err
+		}
+		if pos, err = p.number(t); err != nil {
+			return nil, err
+		}
+		if pos < 1 {
+			return nil, p.errorf(t, "positions count from 1")
+		}
+		if _, err := p.expect(tkComma, "','"); err != nil {
+			return nil, err
+		}
+	}
+	arg, err := p.expect(tkString, "a quoted string")
+	if err != nil {
+		return nil, err
+	}
+	end, err := p.expect(tkRParen, "')'")
+	if err != nil {
+		return nil, err
+	}
+	base := leafBase{
+		id:  len(p.leaves),
+		col: name.pos + 1,
+		src: p.source(name, end),
+	}
+
+	var l leaf
+	switch name.text {
+	case "prefix", "suffix", "position":
+		pat, err := base32check.ParsePattern(arg.text)
+		if err != nil {
+			return nil, p.errorf(arg, "%s", err)
+		}
+		if len(pat) == 0 {
+			return nil, p.errorf(arg, "%s needs at least one character", name.text)
+		}
+		n := &atNode{leafBase: base, p: pat}
+		switch name.text {
+		case "prefix":
+			if base32check.IsLiteral(arg.text) && len(pat) <= base32check.MaxMaskLen {
+				n.mask = base32check.MustPrefixMask(arg.text)
+			}
+		case "suffix":
+			n.off = -len(pat)
+		case "position":
+			n.off = pos - 1
+		}
+		l = n
+	case "contains":
+		word, err := base32check.NewSubstring(arg.text)
+		if err != nil {
+			return nil, p.errorf(arg, "%s", err)
+		}
+		l = &containsNode{leafBase: base, word: word, d: dfa.MustCompile(strings.ToLower(arg.text))}
+	case "regex":
+		d, err := dfa.Compile(arg.text)
+		if err != nil {
+			return nil, p.errorf(arg, "invalid regex: %s", err)
+		}
+		l = &regexNode{leafBase: base, d: d}
+	case "count":
+		if arg.text == "" || strings.ContainsAny(arg.text, "[]") {
+			return nil, p.errorf(arg, "count needs characters such as \"7\", \"aeiou\" or \"2-7\"")
+		}
+		set, err := base32check.ParsePattern("[" + arg.text + "]")
+		if err != nil {
+			return nil, p.errorf(arg, "%s", err)
+		}
+		op, err := p.expect(tkCompare, "a comparison after count(...), e.g. count(\"7\") < 3")
+		if err != nil {
+			return nil, err
+		}
+		t, err := p.expect(tkNumber, "a number")
+		if err != nil {
+			return nil, err
+		}
+		n, err := p.number(t)
+		if err != nil {
+			return nil, err
+		}
+		base.src = p.source(name, t)
+		l = &countNode{leafBase: base, set: set[0], op: op.text, n: n}
+	}
+	if t := p.peek(); t.kind == tkCompare {
+		return nil, p.errorf(t, "only count(...) can be compared")
+	}
+	p.leaves = append(p.leaves, l)
+	return l, nil
+}
+
+func (p *parser) number(t token) (int, error) {
+	n, err := strconv.Atoi(t.text)
+	if err != nil || n > 1000 {
+		return 0, p.errorf(t, "number %s is out of range", t.text)
+	}
+	return n, nil
+}
+
+// source returns the source text from token first through token last.
+func (p *parser) source(first, last token) string {
+	var b strings.Builder
+	for _, t := range p.toks {
+		if t.pos < first.pos || t.pos > last.pos {
+			continue
+		}
+		switch t.kind {
+		case tkString:
+			b.WriteString(strconv.Quote(t.text))
+		case tkComma:
+			b.WriteString(", ")
+		case tkCompare:
+			b.WriteString(" " + t.text + " ")
+		default:
+			b.WriteString(t.text)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,73 @@
+// Package format renders search statistics for humans.
+package format
+
+import (
+	"fmt"
+	"time"
+)
+
+// Number formats a rate such as keys/sec with a K/M suffix.
+func Number(n float64) string {
+	if n >= 1_000_000 {
+		return fmt.Sprintf("%.2fM", n/1_000_000)
+	}
+	if n >= 1_000 {
+		return fmt.Sprintf("%.1fK", n/1_000)
+	}
+	return fmt.Sprintf("%.0f", n)
+}
+
+// Uint formats a counter with a K/M/B suffix.
+func Uint(n uint64) string {
+	if n >= 1_000_000_000 {
+		return fmt.Sprintf("%.2fB", float64(n)/1_000_000_000)
+	}
+	if n >= 1_000_000 {
+		return fmt.Sprintf("%.2fM", float64(n)/1_000_000)
+	}
+	if n >= 1_000 {
+		return fmt.Sprintf("%.1fK", float64(n)/1_000)
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+// Duration formats d as e.g. "3d 4h 12m", "1h 2m 3s" or "42s".
+func Duration(d time.Duration) string {
+	if d < time.Second {
+		return "< 1s"
+	}
+	d = d.Round(time.Second)
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	sec := int(d.Seconds()) % 60
+
+	if h > 24 {
+		days := h / 24
+		h = h % 24
+		return fmt.Sprintf("%dd %dh %dm", days, h, m)
+	}
+	if h > 0 {
+		return fmt.Sprintf("%dh %dm %ds", h, m, sec)
+	}
+	if m > 0 {
+		return fmt.Sprintf("%dm %ds", m, sec)
+	}
+	return fmt.Sprintf("%ds", sec)
+}
+
+// ETA formats the expected remaining time given the estimated attempts,
+// the number already checked and the current rate.
+func ETA(attempts float64, checked uint64, keysPerSec float64) string {
+	if keysPerSec <= 0 {
+		return "-"
+	}
+	remaining := attempts - float64(checked)
+	if remaining < 0 {
+		remaining = 0
+	}
+	secs := remaining / keysPerSec
+	if secs < 1 {
+		return "< 1 second"
+	}
+	return "~" + Duration(time.Duration(secs*float64(time.Second)))
+}
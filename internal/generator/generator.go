@@ -2,12 +2,18 @@ package generator
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/go-i2p/i2p-vanitygen/internal/address"
+	"github.com/go-i2p/i2p-vanitygen/internal/base32check"
+	"github.com/go-i2p/i2p-vanitygen/internal/dfa"
+	"github.com/go-i2p/i2p-vanitygen/internal/expr"
 	"github.com/go-i2p/i2p-vanitygen/internal/gpu"
 )
 
@@ -15,8 +21,10 @@ import (
 type Result struct {
 	Candidate address.Candidate
 	Address   string
-	Attempts  uint64
-	Duration  time.Duration
+	// Target is the prefix this result matched.
+	Target   string
+	Attempts uint64
+	Duration time.Duration
 }
 
 // Stats holds progress information for the search.
@@ -24,30 +32,197 @@ type Stats struct {
 	Checked    uint64
 	KeysPerSec float64
 	Elapsed    time.Duration
+	// BestLen is the longest run of leading prefix characters any candidate
+	// has matched so far, and Best is that candidate, ready to be saved.
+	// Only single-prefix searches track it; Best is nil until a candidate
+	// matches at least one character.
+	BestLen int
+	Best    *Result
+}
+
+// Config describes a search. Prefix and Suffix may each be empty, but not both.
+type Config struct {
+	Scheme address.Scheme
+	Prefix string
+	// Prefixes are further targets searched for in the same pass. The
+	// search runs until each of them and Prefix has been matched once.
+	Prefixes []string
+	Suffix   string // characters the address must end with, before the network suffix
+	// Regex, if set, replaces Prefix, Prefixes and Suffix with an expression
+	// the address must match (see package dfa).
+	Regex string
+	// Contains, if set, replaces Prefix, Prefixes and Suffix with a word
+	// that may occur anywhere in the address.
+	Contains string
+	// Expr, if set, replaces Prefix, Prefixes and Suffix with an expression
+	// combining several conditions (see package expr).
+	Expr string
+	// Matcher, if set, replaces Prefix, Prefixes, Suffix, Regex, Contains
+	// and Expr with custom acceptance logic over each candidate's raw
+	// address bytes. address.PrefixMatcher builds the prefix search as one.
+	Matcher address.Matcher
+	// Reject, if set, vetoes matches it accepts, so the search goes on past
+	// them; blocklist.Blocklist rejects addresses containing unwanted words.
+	// It sees the whole address of a match, like Matcher.
+	Reject address.Matcher
+	// Continuous keeps every worker searching after a match and streams each
+	// match as its own Result, until Count matches have been found or, if
+	// Count is 0, until Stop is called or the context ends. It runs on the
+	// CPU only.
+	Continuous bool
+	Count      int
+	Cores      int
+	GPU        bool
+	GPUDevice  int
 }
 
 // Generator coordinates parallel vanity address searching.
 type Generator struct {
-	scheme    address.Scheme
-	prefix    string
-	numCores  int
-	useGPU    bool
-	gpuDevice int
-	cancel    context.CancelFunc
-	mu        sync.Mutex
+	scheme     address.Scheme
+	prefix     string
+	pattern    base32check.Pattern     // set unless prefix is literal and there is no suffix
+	mask       *base32check.PrefixMask // set for a single literal prefix without suffix
+	track      base32check.Pattern     // the prefix whose best partial match is tracked
+	targets    []string                // set when searching for more than one prefix
+	trie       *base32check.PrefixTrie
+	suffix     string
+	matcher    address.Matcher // regex, contains, expression or custom acceptance logic
+	reject     address.Matcher
+	continuous bool
+	count      int
+	collected  atomic.Int64 // matches sent in continuous mode
+	numCores   int
+	useGPU     bool
+	gpuDevice  int
+	best       *bestTracker
+	cancel     context.CancelFunc
+	mu         sync.Mutex
+}
+
+// New creates a new vanity generator for a prefix.
+func New(scheme address.Scheme, prefix string, numCores int, useGPU bool, gpuDevice int) (*Generator, error) {
+	return NewWithConfig(Config{Scheme: scheme, Prefix: prefix, Cores: numCores, GPU: useGPU, GPUDevice: gpuDevice})
 }
 
-// New creates a new vanity generator.
-func New(scheme address.Scheme, prefix string, numCores int, useGPU bool, gpuDevice int) *Generator {
-	return &Generator{
-		scheme:    scheme,
-		prefix:    strings.ToLower(prefix),
-		numCores:  numCores,
-		useGPU:    useGPU,
-		gpuDevice: gpuDevice,
+// NewWithConfig creates a new vanity generator from cfg. It returns an error
+// if a prefix, suffix, regex, word or expression in cfg is invalid, or if
+// cfg has nothing to search for.
+func NewWithConfig(cfg Config) (*Generator, error) {
+	if cfg.Scheme == nil {
+		return nil, errors.New("no address scheme")
+	}
+	g := &Generator{
+		scheme:     cfg.Scheme,
+		prefix:     strings.ToLower(cfg.Prefix),
+		suffix:     strings.ToLower(cfg.Suffix),
+		continuous: cfg.Continuous,
+		count:      cfg.Count,
+		reject:     cfg.Reject,
+		numCores:   cfg.Cores,
+		useGPU:     cfg.GPU,
+		gpuDevice:  cfg.GPUDevice,
+	}
+	if targets := Targets(cfg.Prefix, cfg.Prefixes); len(targets) > 1 {
+		g.targets = targets
+		g.prefix = ""
+	} else if len(targets) == 1 {
+		g.prefix = targets[0]
+	}
+
+	// A Matcher, expression, word or regex replaces the prefix search, in
+	// that order of precedence.
+	switch {
+	case cfg.Matcher != nil:
+		g.matcher = cfg.Matcher
+	case cfg.Expr != "":
+		e, err := expr.Compile(cfg.Expr)
+		if err == nil {
+			err = e.Validate(cfg.Scheme)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression: %w", err)
+		}
+		g.matcher = e
+	case cfg.Contains != "":
+		word, err := base32check.NewSubstring(cfg.Contains)
+		if err != nil {
+			return nil, err
+		}
+		g.matcher = address.MatcherFunc(word.In)
+	case cfg.Regex != "":
+		d, err := dfa.Compile(cfg.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex: %w", err)
+		}
+		g.matcher = d
+	}
+	if g.matcher != nil {
+		g.prefix, g.targets, g.suffix = "", nil, ""
+		return g, nil
 	}
+
+	if g.prefix == "" && g.targets == nil && g.suffix == "" {
+		return nil, errors.New("nothing to search for: set a prefix, suffix, regex, word, expression or Matcher")
+	}
+	for _, t := range g.targets {
+		if _, err := base32check.ParsePattern(t); err != nil {
+			return nil, fmt.Errorf("prefix %q: %w", t, err)
+		}
+	}
+	if g.targets != nil {
+		g.trie = base32check.NewPrefixTrie(g.targets)
+	}
+	if g.suffix != "" {
+		if err := g.scheme.ValidateSuffix(g.suffix); err != nil {
+			return nil, err
+		}
+	}
+	if g.targets == nil {
+		p, err := base32check.ParsePattern(g.prefix)
+		if err != nil {
+			return nil, fmt.Errorf("prefix %q: %w", g.prefix, err)
+		}
+		if g.suffix != "" || !base32check.IsLiteral(g.prefix) {
+			g.pattern = p
+		}
+		if g.prefix != "" {
+			g.track = p
+			if g.pattern == nil {
+				if g.mask, err = base32check.NewPrefixMask(g.prefix); err != nil {
+					return nil, fmt.Errorf("prefix %q: %w", g.prefix, err)
+				}
+			}
+		}
+	}
+	return g, nil
 }
 
+// Targets returns prefix and prefixes lowercased, without duplicates and
+// in their original order, skipping an empty prefix.
+func Targets(prefix string, prefixes []string) []string {
+	var targets []string
+	for _, p := range append([]string{prefix}, prefixes...) {
+		p = strings.ToLower(p)
+		if p != "" && !slices.Contains(targets, p) {
+			targets = append(targets, p)
+		}
+	}
+	return targets
+}
+
+// UsesGPU reports whether Start will run a GPU worker. The GPU kernels only
+// stop at the first match of a single literal prefix, so suffix, pattern,
+// multi-prefix, regex, contains, expression, custom Matcher and continuous
+// searches run on the CPU alone.
+func (g *Generator) UsesGPU() bool {
+	return g.useGPU && g.pattern == nil && g.targets == nil && g.matcher == nil &&
+		!g.continuous && g.scheme.SupportsGPU() && gpu.Available()
+}
+
+// TracksBest reports whether Stats will carry the best partial match, which
+// is only tracked for a single prefix.
+func (g *Generator) TracksBest() bool { return g.track != nil }
+
 // Start begins the parallel vanity search. Returns channels for results and stats.
 func (g *Generator) Start(ctx context.Context) (<-chan Result, <-chan Stats) {
 	ctx, cancel := context.WithCancel(ctx)
@@ -55,11 +230,19 @@ func (g *Generator) Start(ctx context.Context) (<-chan Result, <-chan Stats) {
 	g.cancel = cancel
 	g.mu.Unlock()
 
-	resultCh := make(chan Result, 1)
+	// Every target is reported at most once, so sends never block. In
+	// continuous mode sends may block until the caller reads them.
+	resultCh := make(chan Result, max(1, len(g.targets)))
+	if g.continuous {
+		resultCh = make(chan Result, max(1, g.numCores))
+	}
 	statsCh := make(chan Stats, 1)
 
 	var totalChecked atomic.Uint64
 	var found atomic.Bool
+	p := newPending(len(g.targets))
+	g.best = &bestTracker{}
+	g.collected.Store(0)
 	startTime := time.Now()
 
 	var workerWg sync.WaitGroup
@@ -67,7 +250,7 @@ func (g *Generator) Start(ctx context.Context) (<-chan Result, <-chan Stats) {
 
 	// Launch GPU worker if enabled and scheme supports it
 	cpuWorkerOffset := 0
-	if g.useGPU && g.scheme.SupportsGPU() && gpu.Available() {
+	if g.UsesGPU() {
 		cpuWorkerOffset = 1 // reserve workerID 0 counter space for GPU
 		workerWg.Add(1)
 		go func() {
@@ -86,7 +269,7 @@ func (g *Generator) Start(ctx context.Context) (<-chan Result, <-chan Stats) {
 		workerWg.Add(1)
 		go func(workerID int) {
 			defer workerWg.Done()
-			g.worker(ctx, workerID+cpuWorkerOffset, &totalChecked, &found, resultCh)
+			g.worker(ctx, workerID+cpuWorkerOffset, &totalChecked, &found, p, resultCh)
 		}(i)
 	}
 
@@ -107,11 +290,14 @@ func (g *Generator) Start(ctx context.Context) (<-chan Result, <-chan Stats) {
 				if elapsed.Seconds() > 0 {
 					kps = float64(checked) / elapsed.Seconds()
 				}
+				bestLen, best := g.best.get()
 				select {
 				case statsCh <- Stats{
 					Checked:    checked,
 					KeysPerSec: kps,
 					Elapsed:    elapsed,
+					BestLen:    bestLen,
+					Best:       best,
 				}:
 				default:
 				}
@@ -140,6 +326,18 @@ func (g *Generator) Stop() {
 	}
 }
 
+// i2pWorkerConfig describes a GPU search that mutates cand's destination the
+// way MutatePadding does.
+func (g *Generator) i2pWorkerConfig(cand *address.I2PCandidate, batchSize uint64) gpu.WorkerConfig {
+	return gpu.WorkerConfig{
+		DeviceIndex:   g.gpuDevice,
+		DestTemplate:  cand.Raw(),
+		CounterOffset: cand.Dest.CounterOffset(),
+		Prefix:        g.prefix,
+		BatchSize:     batchSize,
+	}
+}
+
 func (g *Generator) gpuWorker(ctx context.Context, totalChecked *atomic.Uint64, found *atomic.Bool, resultCh chan<- Result, startTime time.Time) {
 	// GPU only works with I2P scheme (needs the raw destination template)
 	cand, err := g.scheme.NewCandidate()
@@ -152,12 +350,7 @@ func (g *Generator) gpuWorker(ctx context.Context, totalChecked *atomic.Uint64,
 	}
 
 	batchSize := uint64(1 << 22) // ~4M hashes per dispatch
-	gpuW, err := gpu.NewWorker(gpu.WorkerConfig{
-		DeviceIndex:  g.gpuDevice,
-		DestTemplate: i2pCand.Raw(),
-		Prefix:       g.prefix,
-		BatchSize:    batchSize,
-	})
+	gpuW, err := gpu.NewWorker(g.i2pWorkerConfig(i2pCand, batchSize))
 	if err != nil {
 		return // GPU unavailable, CPU workers continue
 	}
@@ -184,12 +377,19 @@ func (g *Generator) gpuWorker(ctx context.Context, totalChecked *atomic.Uint64,
 		counter += result.Checked
 
 		if result.Found {
+			// Reconstruct the matching destination on CPU
+			i2pCand.Dest.MutatePadding(result.MatchCounter)
+			if g.rejected(i2pCand) {
+				// The kernel stops at the first match, so the rest of
+				// this batch goes unchecked; rejections are rare enough
+				// not to matter.
+				continue
+			}
 			if found.CompareAndSwap(false, true) {
-				// Reconstruct the matching destination on CPU
-				i2pCand.Dest.MutateEncryptionKey(result.MatchCounter)
 				resultCh <- Result{
 					Candidate: i2pCand,
 					Address:   i2pCand.FullAddress(),
+					Target:    g.prefix,
 					Attempts:  totalChecked.Load(),
 					Duration:  time.Since(startTime),
 				}
@@ -256,12 +456,16 @@ func (g *Generator) torV3GPUWorker(ctx context.Context, totalChecked *atomic.Uin
 		totalChecked.Add(result.Checked)
 
 		if result.Found {
+			// Reconstruct matching candidate from snapshot
+			snapshot.AdvanceBy(result.MatchCounter)
+			if g.rejected(snapshot) {
+				continue // as in gpuWorker
+			}
 			if found.CompareAndSwap(false, true) {
-				// Reconstruct matching candidate from snapshot
-				snapshot.AdvanceBy(result.MatchCounter)
 				resultCh <- Result{
 					Candidate: snapshot,
 					Address:   snapshot.FullAddress(),
+					Target:    g.prefix,
 					Attempts:  totalChecked.Load(),
 					Duration:  time.Since(startTime),
 				}
@@ -271,28 +475,28 @@ func (g *Generator) torV3GPUWorker(ctx context.Context, totalChecked *atomic.Uin
 	}
 }
 
-func (g *Generator) worker(ctx context.Context, workerID int, totalChecked *atomic.Uint64, found *atomic.Bool, resultCh chan<- Result) {
+func (g *Generator) worker(ctx context.Context, workerID int, totalChecked *atomic.Uint64, found *atomic.Bool, p *pending, resultCh chan<- Result) {
 	startTime := time.Now()
 
 	switch g.scheme.Network() {
 	case address.NetworkI2P:
-		g.i2pWorker(ctx, workerID, totalChecked, found, resultCh, startTime)
+		g.i2pWorker(ctx, workerID, totalChecked, found, p, resultCh, startTime)
 	case address.NetworkTorV3:
-		g.torV3Worker(ctx, workerID, totalChecked, found, resultCh, startTime)
+		g.torV3Worker(ctx, workerID, totalChecked, found, p, resultCh, startTime)
 	}
 }
 
-func (g *Generator) i2pWorker(ctx context.Context, workerID int, totalChecked *atomic.Uint64, found *atomic.Bool, resultCh chan<- Result, startTime time.Time) {
-	cand, err := g.scheme.NewCandidate()
+func (g *Generator) i2pWorker(ctx context.Context, workerID int, totalChecked *atomic.Uint64, found *atomic.Bool, p *pending, resultCh chan<- Result, startTime time.Time) {
+	i2pCand, err := g.newI2PCandidate()
 	if err != nil {
 		return
 	}
-	i2pCand := cand.(*address.I2PCandidate)
 
 	baseCounter := uint64(workerID) << 48
 	counter := baseCounter
 	batchSize := uint64(1024)
 	localChecked := uint64(0)
+	var matched []int
 	flushChecked := func() uint64 {
 		if localChecked == 0 {
 			return totalChecked.Load()
@@ -316,19 +520,39 @@ func (g *Generator) i2pWorker(ctx context.Context, workerID int, totalChecked *a
 			}
 		}
 
-		if i2pCand.MutateAndCheck(counter, g.prefix) {
+		if g.trie != nil {
+			matched = i2pCand.MutateAndMatchTargets(counter, g.trie, g.suffix, matched[:0])
+			if len(matched) > 0 && !g.rejected(i2pCand) {
+				localChecked++
+				counter++
+				reported, done := g.reportTargets(ctx, i2pCand, matched, p, found, resultCh, flushChecked(), startTime)
+				if done {
+					return
+				}
+				if reported {
+					if i2pCand, err = g.newI2PCandidate(); err != nil {
+						return
+					}
+					counter = baseCounter
+				}
+				continue
+			}
+		} else if ok, n := g.i2pMatch(i2pCand, counter); ok && !g.rejected(i2pCand) {
 			localChecked++
 			attempts := flushChecked()
 			counter++
-			if found.CompareAndSwap(false, true) {
-				resultCh <- Result{
-					Candidate: i2pCand,
-					Address:   i2pCand.FullAddress(),
-					Attempts:  attempts,
-					Duration:  time.Since(startTime),
-				}
+			if g.report(ctx, i2pCand, g.prefix, found, resultCh, attempts, startTime) {
+				return
 			}
-			return
+			// The reported candidate is handed over, so later matches
+			// need fresh key material.
+			if i2pCand, err = g.newI2PCandidate(); err != nil {
+				return
+			}
+			counter = baseCounter
+			continue
+		} else if n > g.best.len() && !g.rejected(i2pCand) {
+			g.best.offer(n, i2pCand.Clone(), g.prefix, totalChecked.Load()+localChecked+1, startTime)
 		}
 
 		counter++
@@ -339,7 +563,7 @@ func (g *Generator) i2pWorker(ctx context.Context, workerID int, totalChecked *a
 	}
 }
 
-func (g *Generator) torV3Worker(ctx context.Context, workerID int, totalChecked *atomic.Uint64, found *atomic.Bool, resultCh chan<- Result, startTime time.Time) {
+func (g *Generator) torV3Worker(ctx context.Context, workerID int, totalChecked *atomic.Uint64, found *atomic.Bool, p *pending, resultCh chan<- Result, startTime time.Time) {
 	cand, err := address.NewTorV3Candidate()
 	if err != nil {
 		return
@@ -353,6 +577,7 @@ func (g *Generator) torV3Worker(ctx context.Context, workerID int, totalChecked
 	batchSize := uint64(1024)
 	checked := uint64(0)
 	localChecked := uint64(0)
+	var matched []int
 	flushChecked := func() uint64 {
 		if localChecked == 0 {
 			return totalChecked.Load()
@@ -376,19 +601,39 @@ func (g *Generator) torV3Worker(ctx context.Context, workerID int, totalChecked
 			}
 		}
 
-		if cand.CheckPrefix(g.prefix) {
+		if g.trie != nil {
+			matched = cand.MatchTargets(g.trie, g.suffix, matched[:0])
+			if len(matched) > 0 && !g.rejected(cand) {
+				localChecked++
+				checked++
+				reported, done := g.reportTargets(ctx, cand, matched, p, found, resultCh, flushChecked(), startTime)
+				if done {
+					return
+				}
+				if reported {
+					// Keys derived from a reported one are related to it,
+					// so start over from a new random key.
+					if cand, err = address.NewTorV3Candidate(); err != nil {
+						return
+					}
+				} else {
+					cand.Advance()
+				}
+				continue
+			}
+		} else if ok, n := g.torV3Match(cand); ok && !g.rejected(cand) {
 			localChecked++
 			checked++
 			attempts := flushChecked()
-			if found.CompareAndSwap(false, true) {
-				resultCh <- Result{
-					Candidate: cand,
-					Address:   cand.FullAddress(),
-					Attempts:  attempts,
-					Duration:  time.Since(startTime),
-				}
+			if g.report(ctx, cand, g.prefix, found, resultCh, attempts, startTime) {
+				return
 			}
-			return
+			if cand, err = address.NewTorV3Candidate(); err != nil {
+				return
+			}
+			continue
+		} else if n > g.best.len() && !g.rejected(cand) {
+			g.best.offer(n, cand.Clone(), g.prefix, totalChecked.Load()+localChecked+1, startTime)
 		}
 
 		cand.Advance()
@@ -399,3 +644,176 @@ func (g *Generator) torV3Worker(ctx context.Context, workerID int, totalChecked
 		}
 	}
 }
+
+// i2pMatch mutates c and reports whether it matches the search, along with
+// how many prefix characters it matched when the best match is tracked.
+func (g *Generator) i2pMatch(c *address.I2PCandidate, counter uint64) (bool, int) {
+	if g.matcher != nil {
+		return c.MutateAndMatchWith(counter, g.matcher), 0
+	}
+	if g.mask != nil {
+		n := c.MutateAndCheckLen(counter, g.mask)
+		return n == g.mask.Len(), n
+	}
+	if g.track != nil {
+		n, ok := c.MutateAndMatchLen(counter, g.track, g.suffix)
+		return ok, n
+	}
+	return c.MutateAndMatch(counter, g.pattern, g.suffix), 0
+}
+
+// torV3Match is i2pMatch for the current Tor v3 candidate.
+func (g *Generator) torV3Match(c *address.TorV3Candidate) (bool, int) {
+	if g.matcher != nil {
+		return c.MatchWith(g.matcher), 0
+	}
+	if g.mask != nil {
+		n := c.CheckPrefixLen(g.mask)
+		return n == g.mask.Len(), n
+	}
+	if g.track != nil {
+		n, ok := c.MatchLen(g.track, g.suffix)
+		return ok, n
+	}
+	return c.Matches(g.pattern, g.suffix), 0
+}
+
+// rejected reports whether the search's Reject matcher vetoes the current
+// address of c.
+func (g *Generator) rejected(c interface{ MatchWith(address.Matcher) bool }) bool {
+	return g.reject != nil && c.MatchWith(g.reject)
+}
+
+// pending tracks which targets of a multi-prefix search are still unmatched.
+type pending struct {
+	done      []atomic.Bool
+	remaining atomic.Int64
+}
+
+func newPending(n int) *pending {
+	p := &pending{done: make([]atomic.Bool, n)}
+	p.remaining.Store(int64(n))
+	return p
+}
+
+// claim marks target i as matched. It reports whether this call matched it
+// first and whether it was the last unmatched target.
+func (p *pending) claim(i int) (first, last bool) {
+	if !p.done[i].CompareAndSwap(false, true) {
+		return false, false
+	}
+	return true, p.remaining.Add(-1) == 0
+}
+
+// newI2PCandidate returns an I2P candidate with a fresh signing key.
+func (g *Generator) newI2PCandidate() (*address.I2PCandidate, error) {
+	cand, err := g.scheme.NewCandidate()
+	if err != nil {
+		return nil, err
+	}
+	return cand.(*address.I2PCandidate), nil
+}
+
+// report sends cand as a match for target. It returns true when the search
+// is over: after the first match, or in continuous mode once Count matches
+// have been sent or the search is cancelled. The worker must not mutate cand
+// afterwards.
+func (g *Generator) report(ctx context.Context, cand address.Candidate, target string, found *atomic.Bool, resultCh chan<- Result, attempts uint64, startTime time.Time) bool {
+	r := Result{
+		Candidate: cand,
+		Address:   cand.FullAddress(),
+		Target:    target,
+		Attempts:  attempts,
+		Duration:  time.Since(startTime),
+	}
+	if !g.continuous {
+		if found.CompareAndSwap(false, true) {
+			resultCh <- r
+		}
+		return true
+	}
+
+	n := g.collected.Add(1)
+	if g.count > 0 && n > int64(g.count) {
+		return true // another worker sent the last one
+	}
+	select {
+	case resultCh <- r:
+	case <-ctx.Done():
+		return true
+	}
+	if g.count > 0 && n == int64(g.count) {
+		found.Store(true)
+		return true
+	}
+	return false
+}
+
+// reportTargets reports cand for every target in matched, or in a
+// multi-prefix search that is not continuous, for every one no worker has
+// matched yet. It reports whether cand was sent, and whether the search is
+// over because all targets are matched.
+func (g *Generator) reportTargets(ctx context.Context, cand address.Candidate, matched []int, p *pending, found *atomic.Bool, resultCh chan<- Result, attempts uint64, startTime time.Time) (reported, done bool) {
+	for _, i := range matched {
+		if g.continuous {
+			reported = true
+			if g.report(ctx, cand, g.targets[i], found, resultCh, attempts, startTime) {
+				return true, true
+			}
+			continue
+		}
+		first, last := p.claim(i)
+		if !first {
+			continue
+		}
+		reported = true
+		resultCh <- Result{
+			Candidate: cand,
+			Address:   cand.FullAddress(),
+			Target:    g.targets[i],
+			Attempts:  attempts,
+			Duration:  time.Since(startTime),
+		}
+		if last {
+			found.Store(true)
+			return true, true
+		}
+	}
+	return reported, found.Load()
+}
+
+// bestTracker holds the candidate that has matched the most prefix
+// characters so far. Improvements are rare, so a mutex is cheap; workers
+// check len first to skip it.
+type bestTracker struct {
+	n      atomic.Int64
+	mu     sync.Mutex
+	result *Result
+}
+
+func (b *bestTracker) len() int { return int(b.n.Load()) }
+
+// offer records cand, which matched n characters, if no earlier candidate
+// matched as many. cand must not be mutated afterwards.
+func (b *bestTracker) offer(n int, cand address.Candidate, target string, attempts uint64, startTime time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if n <= b.len() {
+		return
+	}
+	b.n.Store(int64(n))
+	b.result = &Result{
+		Candidate: cand,
+		Address:   cand.FullAddress(),
+		Target:    target,
+		Attempts:  attempts,
+		Duration:  time.Since(startTime),
+	}
+}
+
+// get returns the best length and its result, which is never modified.
+func (b *bestTracker) get() (int, *Result) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.len(), b.result
+}
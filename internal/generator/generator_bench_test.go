@@ -9,7 +9,10 @@ import (
 )
 
 func runI2PThroughputSample(cores int, d time.Duration) float64 {
-	g := New(address.I2PScheme{}, "zzzzzzzz", cores, false, 0)
+	g, err := New(address.I2PScheme{}, "zzzzzzzz", cores, false, 0)
+	if err != nil {
+		panic(err)
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	resultCh, statsCh := g.Start(ctx)
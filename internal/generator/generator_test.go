@@ -0,0 +1,341 @@
+package generator
+
+import (
+	"context"
+	"encoding/binary"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/address"
+	"github.com/go-i2p/i2p-vanitygen/internal/base32check"
+)
+
+func mustNew(t *testing.T, cfg Config) *Generator {
+	t.Helper()
+	g, err := NewWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestInvalidConfig(t *testing.T) {
+	s := address.I2PScheme{}
+	for _, cfg := range []Config{
+		{},
+		{Scheme: s},
+		{Scheme: s, Prefix: "a1"},
+		{Scheme: s, Prefixes: []string{"ab", "[a"}},
+		{Scheme: s, Suffix: "b"},
+		{Scheme: s, Regex: "a("},
+		{Scheme: s, Contains: "x!"},
+		{Scheme: s, Expr: `prefix("a") &&`},
+		{Scheme: s, Expr: `suffix("cd")`}, // an I2P address never ends in d
+	} {
+		if g, err := NewWithConfig(cfg); err == nil {
+			t.Errorf("NewWithConfig(%+v) = %v, want an error", cfg, g)
+		}
+	}
+}
+
+// TestI2PWorkerConfig checks that the GPU is told to write its counter where
+// MutatePadding does, by applying the config to the template on the CPU.
+func TestI2PWorkerConfig(t *testing.T) {
+	for _, scheme := range []address.I2PScheme{{}, {X25519: true}} {
+		g := mustNew(t, Config{Scheme: scheme, Prefix: "a"})
+		cand, err := scheme.NewCandidate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		i2pCand := cand.(*address.I2PCandidate)
+		cfg := g.i2pWorkerConfig(i2pCand, 1024)
+		if cfg.CounterOffset != i2pCand.Dest.CounterOffset() {
+			t.Errorf("X25519=%v: counter offset %d, want %d", scheme.X25519, cfg.CounterOffset, i2pCand.Dest.CounterOffset())
+		}
+
+		const counter = 0x0102030405060708
+		raw := cfg.DestTemplate
+		binary.LittleEndian.PutUint64(raw[cfg.CounterOffset:], counter)
+		i2pCand.Dest.MutatePadding(counter)
+		if raw != i2pCand.Raw() {
+			t.Errorf("X25519=%v: GPU counter placement differs from MutatePadding", scheme.X25519)
+		}
+	}
+}
+
+func TestMultiPrefixSearch(t *testing.T) {
+	for _, scheme := range []address.Scheme{address.I2PScheme{}, address.TorV3Scheme{}} {
+		t.Run(scheme.Network().String(), func(t *testing.T) {
+			g := mustNew(t, Config{
+				Scheme:   scheme,
+				Prefix:   "a",
+				Prefixes: []string{"B", "c", "a", "d2"},
+				Cores:    2,
+			})
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			resultCh, statsCh := g.Start(ctx)
+			go func() {
+				for range statsCh {
+				}
+			}()
+
+			var got []string
+			for r := range resultCh {
+				if !strings.HasPrefix(r.Address, r.Target) {
+					t.Errorf("result %s does not start with its target %q", r.Address, r.Target)
+				}
+				if r.Candidate.FullAddress() != r.Address {
+					t.Errorf("candidate address %s changed after it was reported as %s", r.Candidate.FullAddress(), r.Address)
+				}
+				got = append(got, r.Target)
+			}
+			slices.Sort(got)
+			if want := []string{"a", "b", "c", "d2"}; !slices.Equal(got, want) {
+				t.Errorf("matched targets %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestTargets(t *testing.T) {
+	got := Targets("Web", []string{"mail", "web", "", "GIT", "mail"})
+	if want := []string{"web", "mail", "git"}; !slices.Equal(got, want) {
+		t.Errorf("Targets = %v, want %v", got, want)
+	}
+	if got := Targets("", nil); got != nil {
+		t.Errorf("Targets of nothing = %v, want nil", got)
+	}
+}
+
+func TestRegexSearch(t *testing.T) {
+	for _, scheme := range []address.Scheme{address.I2PScheme{}, address.TorV3Scheme{}} {
+		t.Run(scheme.Network().String(), func(t *testing.T) {
+			g := mustNew(t, Config{Scheme: scheme, Regex: "^[a-d]?[2-7]", Cores: 1})
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			resultCh, statsCh := g.Start(ctx)
+			go func() {
+				for range statsCh {
+				}
+			}()
+			r, ok := <-resultCh
+			if !ok {
+				t.Fatal("no result")
+			}
+			if !regexp.MustCompile(`^[a-d]?[2-7]`).MatchString(r.Address) {
+				t.Errorf("result %s does not match the expression", r.Address)
+			}
+		})
+	}
+}
+
+func TestContainsSearch(t *testing.T) {
+	for _, scheme := range []address.Scheme{address.I2PScheme{}, address.TorV3Scheme{}} {
+		t.Run(scheme.Network().String(), func(t *testing.T) {
+			g := mustNew(t, Config{Scheme: scheme, Contains: "Xy", Cores: 1})
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			resultCh, statsCh := g.Start(ctx)
+			go func() {
+				for range statsCh {
+				}
+			}()
+			r, ok := <-resultCh
+			if !ok {
+				t.Fatal("no result")
+			}
+			if addr := strings.TrimSuffix(r.Address, scheme.Suffix()); !strings.Contains(addr, "xy") {
+				t.Errorf("result %s does not contain \"xy\"", r.Address)
+			}
+		})
+	}
+}
+
+func TestBestSoFar(t *testing.T) {
+	for _, scheme := range []address.Scheme{address.I2PScheme{}, address.TorV3Scheme{}} {
+		t.Run(scheme.Network().String(), func(t *testing.T) {
+			const prefix = "zzzzzzzzzz"
+			g := mustNew(t, Config{Scheme: scheme, Prefix: "ZZZZZZZZZZ", Cores: 1})
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			resultCh, statsCh := g.Start(ctx)
+			go func() {
+				for range resultCh {
+				}
+			}()
+
+			var last Stats
+			for st := range statsCh {
+				if st.BestLen < last.BestLen {
+					t.Errorf("best length went down from %d to %d", last.BestLen, st.BestLen)
+				}
+				last = st
+			}
+			if last.BestLen == 0 || last.Best == nil {
+				t.Fatalf("no best candidate after %d attempts", last.Checked)
+			}
+			if !strings.HasPrefix(last.Best.Address, prefix[:last.BestLen]) || strings.HasPrefix(last.Best.Address, prefix[:last.BestLen+1]) {
+				t.Errorf("best %s does not match exactly %d characters of %q", last.Best.Address, last.BestLen, prefix)
+			}
+			if last.Best.Candidate.FullAddress() != last.Best.Address {
+				t.Errorf("best candidate changed to %s after it was reported as %s", last.Best.Candidate.FullAddress(), last.Best.Address)
+			}
+		})
+	}
+
+	g := mustNew(t, Config{Scheme: address.I2PScheme{}, Regex: "^zzzzzzzzzz", Cores: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	_, statsCh := g.Start(ctx)
+	for st := range statsCh {
+		if st.Best != nil {
+			t.Fatal("regex search reported a best candidate")
+		}
+	}
+}
+
+func TestContinuousSearch(t *testing.T) {
+	for _, scheme := range []address.Scheme{address.I2PScheme{}, address.TorV3Scheme{}} {
+		t.Run(scheme.Network().String(), func(t *testing.T) {
+			g := mustNew(t, Config{Scheme: scheme, Prefix: "a", Continuous: true, Count: 5, Cores: 2})
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			resultCh, statsCh := g.Start(ctx)
+			go func() {
+				for range statsCh {
+				}
+			}()
+
+			var results []Result
+			for r := range resultCh {
+				results = append(results, r)
+			}
+			if len(results) != 5 {
+				t.Fatalf("got %d results, want 5", len(results))
+			}
+			seen := map[string]bool{}
+			for _, r := range results {
+				if !strings.HasPrefix(r.Address, "a") {
+					t.Errorf("result %s does not start with the prefix", r.Address)
+				}
+				if r.Candidate.FullAddress() != r.Address {
+					t.Errorf("candidate address %s changed after it was reported as %s", r.Candidate.FullAddress(), r.Address)
+				}
+				// Every match must come from its own key, not a
+				// mutation of an earlier one.
+				key := r.Address
+				if c, ok := r.Candidate.(*address.I2PCandidate); ok {
+					key = string(c.Dest.SigningPublicKey())
+				}
+				if seen[key] {
+					t.Errorf("result %s shares its key with an earlier result", r.Address)
+				}
+				seen[key] = true
+			}
+		})
+	}
+}
+
+func TestContinuousSearchStop(t *testing.T) {
+	g := mustNew(t, Config{Scheme: address.I2PScheme{}, Prefix: "b", Continuous: true, Cores: 1})
+	resultCh, statsCh := g.Start(context.Background())
+	go func() {
+		for range statsCh {
+		}
+	}()
+
+	n := 0
+	for range resultCh {
+		if n++; n == 3 {
+			g.Stop()
+		}
+	}
+	if n < 3 {
+		t.Errorf("got %d results before stopping, want at least 3", n)
+	}
+}
+
+func TestCustomMatcher(t *testing.T) {
+	// "prefix a and the 4th character is a digit"
+	prefix := base32check.MustPrefixMask("a")
+	m := address.MatcherFunc(func(data []byte) bool {
+		return prefix.Match(data) && base32check.Symbol(data, 3) >= 26
+	})
+	for _, scheme := range []address.Scheme{address.I2PScheme{}, address.TorV3Scheme{}} {
+		t.Run(scheme.Network().String(), func(t *testing.T) {
+			g := mustNew(t, Config{Scheme: scheme, Prefix: "zzzz", Matcher: m, Cores: 1})
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			resultCh, statsCh := g.Start(ctx)
+			go func() {
+				for range statsCh {
+				}
+			}()
+			r, ok := <-resultCh
+			if !ok {
+				t.Fatal("no result")
+			}
+			if !regexp.MustCompile(`^a..[2-7]`).MatchString(r.Address) {
+				t.Errorf("result %s does not satisfy the matcher", r.Address)
+			}
+		})
+	}
+}
+
+func TestReject(t *testing.T) {
+	// Reject every address whose second character is a-p, half of them.
+	reject := address.MatcherFunc(func(data []byte) bool {
+		return base32check.Symbol(data, 1) < 16
+	})
+	for _, scheme := range []address.Scheme{address.I2PScheme{}, address.TorV3Scheme{}} {
+		for _, prefixes := range [][]string{{"a"}, {"a", "b"}} {
+			t.Run(scheme.Network().String()+"/"+strings.Join(prefixes, ","), func(t *testing.T) {
+				g := mustNew(t, Config{Scheme: scheme, Prefixes: prefixes, Reject: reject, Continuous: true, Count: 8, Cores: 1})
+				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				defer cancel()
+				resultCh, statsCh := g.Start(ctx)
+				go func() {
+					for range statsCh {
+					}
+				}()
+				n := 0
+				for r := range resultCh {
+					n++
+					if c := r.Address[1]; c >= 'a' && c <= 'p' {
+						t.Errorf("rejected address %s was reported", r.Address)
+					}
+				}
+				if n == 0 {
+					t.Error("no result")
+				}
+			})
+		}
+	}
+}
+
+func TestExprSearch(t *testing.T) {
+	for _, scheme := range []address.Scheme{address.I2PScheme{}, address.TorV3Scheme{}} {
+		t.Run(scheme.Network().String(), func(t *testing.T) {
+			g := mustNew(t, Config{Scheme: scheme, Expr: `prefix("a") && !position(2, "[a-m]") && count("7") < 2`, Cores: 1})
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			resultCh, statsCh := g.Start(ctx)
+			go func() {
+				for range statsCh {
+				}
+			}()
+			r, ok := <-resultCh
+			if !ok {
+				t.Fatal("no result")
+			}
+			addr := strings.TrimSuffix(strings.TrimSuffix(r.Address, ".b32.i2p"), ".onion")
+			if !regexp.MustCompile(`^a[n-z2-7]`).MatchString(addr) || strings.Count(addr, "7") >= 2 {
+				t.Errorf("result %s does not satisfy the expression", r.Address)
+			}
+		})
+	}
+}
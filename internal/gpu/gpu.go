@@ -1,5 +1,7 @@
 package gpu
 
+import "fmt"
+
 // Device represents a detected GPU compute device.
 type Device struct {
 	Name          string
@@ -10,10 +12,21 @@ type Device struct {
 
 // WorkerConfig configures a GPU vanity search worker.
 type WorkerConfig struct {
-	DeviceIndex  int
-	DestTemplate [391]byte // the 391-byte I2P destination to mutate
-	Prefix       string    // target base32 prefix
-	BatchSize    uint64    // hashes per kernel dispatch (e.g. 1<<22)
+	DeviceIndex   int
+	DestTemplate  [391]byte // the 391-byte I2P destination to mutate
+	CounterOffset int       // where the 8-byte counter goes (destination.Destination.CounterOffset)
+	Prefix        string    // target base32 prefix
+	BatchSize     uint64    // hashes per kernel dispatch (e.g. 1<<22)
+}
+
+// checkCounterOffset rejects a counter position the kernels cannot handle:
+// the counter must fill two whole SHA-256 words of one block, before the
+// certificate.
+func checkCounterOffset(off int) error {
+	if off < 0 || off+8 > 384 || off%4 != 0 || off%64 > 56 {
+		return fmt.Errorf("unsupported counter offset %d", off)
+	}
+	return nil
 }
 
 // BatchResult holds the outcome of one GPU batch.
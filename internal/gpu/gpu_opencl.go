@@ -58,7 +58,8 @@ static const char* kernelSource =
 "    const uint prefix_len,\n"
 "    __global const char* prefix,\n"
 "    __global int* match_found,\n"
-"    __global ulong* match_counter\n"
+"    __global ulong* match_counter,\n"
+"    const uint counter_offset\n"
 ") {\n"
 "    ulong gid = get_global_id(0);\n"
 "    ulong counter = counter_base + gid;\n"
@@ -68,14 +69,7 @@ static const char* kernelSource =
 "    uchar dest[448];\n"
 "    for (uint i = 0; i < 391; i++) dest[i] = dest_template[i];\n"
 "\n"
-"    dest[0] = (uchar)(counter);\n"
-"    dest[1] = (uchar)(counter >> 8);\n"
-"    dest[2] = (uchar)(counter >> 16);\n"
-"    dest[3] = (uchar)(counter >> 24);\n"
-"    dest[4] = (uchar)(counter >> 32);\n"
-"    dest[5] = (uchar)(counter >> 40);\n"
-"    dest[6] = (uchar)(counter >> 48);\n"
-"    dest[7] = (uchar)(counter >> 56);\n"
+"    for (uint i = 0; i < 8; i++) dest[counter_offset + i] = (uchar)(counter >> (8 * i));\n"
 "\n"
 "    dest[391] = 0x80;\n"
 "    for (uint i = 392; i < 440; i++) dest[i] = 0;\n"
@@ -354,7 +348,7 @@ char* oclDeviceVendor(int index) {
     return strdup(vendor);
 }
 
-void* oclNewWorker(int deviceIndex, const unsigned char* destTemplate,
+void* oclNewWorker(int deviceIndex, const unsigned char* destTemplate, int counterOffset,
                    const char* prefix, int prefixLen, unsigned long batchSize) {
     ensureInit();
     if (deviceIndex < 0 || deviceIndex >= g_deviceCount) return NULL;
@@ -428,6 +422,9 @@ void* oclNewWorker(int deviceIndex, const unsigned char* destTemplate,
     if (err != CL_SUCCESS) goto fail;
     err = clSetKernelArg(kern, 5, sizeof(cl_mem), &matchCounterBuf);
     if (err != CL_SUCCESS) goto fail;
+    cl_uint co = (cl_uint)counterOffset;
+    err = clSetKernelArg(kern, 6, sizeof(cl_uint), &co);
+    if (err != CL_SUCCESS) goto fail;
 
     OpenCLWorker* w = (OpenCLWorker*)calloc(1, sizeof(OpenCLWorker));
     if (w == NULL) goto fail;
@@ -720,6 +717,9 @@ func NewWorker(cfg WorkerConfig) (*Worker, error) {
 	if !Available() {
 		return nil, fmt.Errorf("no OpenCL GPU available")
 	}
+	if err := checkCounterOffset(cfg.CounterOffset); err != nil {
+		return nil, err
+	}
 
 	cPrefix := C.CString(cfg.Prefix)
 	defer C.free(unsafe.Pointer(cPrefix))
@@ -727,6 +727,7 @@ func NewWorker(cfg WorkerConfig) (*Worker, error) {
 	handle := C.oclNewWorker(
 		C.int(cfg.DeviceIndex),
 		(*C.uchar)(unsafe.Pointer(&cfg.DestTemplate[0])),
+		C.int(cfg.CounterOffset),
 		cPrefix,
 		C.int(len(cfg.Prefix)),
 		C.ulong(cfg.BatchSize),
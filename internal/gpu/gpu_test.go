@@ -0,0 +1,31 @@
+package gpu
+
+import (
+	"testing"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/address"
+)
+
+// TestCounterOffsets checks that every backend accepts the counter offsets of
+// both destination types, whatever GPU the machine has.
+func TestCounterOffsets(t *testing.T) {
+	for _, tt := range []struct {
+		scheme address.I2PScheme
+		want   int
+	}{
+		{address.I2PScheme{}, 256},
+		{address.I2PScheme{X25519: true}, 32},
+	} {
+		cand, err := tt.scheme.NewCandidate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		off := cand.(*address.I2PCandidate).Dest.CounterOffset()
+		if off != tt.want {
+			t.Errorf("X25519=%v: counter offset %d, want %d", tt.scheme.X25519, off, tt.want)
+		}
+		if err := checkCounterOffset(off); err != nil {
+			t.Errorf("X25519=%v: %v", tt.scheme.X25519, err)
+		}
+	}
+}
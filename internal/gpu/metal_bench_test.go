@@ -30,10 +30,11 @@ func BenchmarkMetalI2PRunBatch(b *testing.B) {
 	i2pCand := candAny.(*address.I2PCandidate)
 
 	worker, err := NewWorker(WorkerConfig{
-		DeviceIndex:  0,
-		DestTemplate: i2pCand.Raw(),
-		Prefix:       "zzzzzzzzzzzz",
-		BatchSize:    benchI2PBatchSize,
+		DeviceIndex:   0,
+		DestTemplate:  i2pCand.Raw(),
+		CounterOffset: i2pCand.Dest.CounterOffset(),
+		Prefix:        "zzzzzzzzzzzz",
+		BatchSize:     benchI2PBatchSize,
 	})
 	if err != nil {
 		b.Fatal(err)
@@ -12,10 +12,11 @@ char** metalListDevices(int* count);
 
 // Creates a new Metal compute worker. Returns an opaque handle, or NULL on failure.
 // destTemplate: 391-byte I2P destination template
+// counterOffset: byte offset of the 8-byte little-endian counter in destTemplate
 // prefix: target base32 prefix string
 // prefixLen: length of prefix
 // batchSize: number of hashes per dispatch
-void* metalNewWorker(int deviceIndex, const unsigned char* destTemplate,
+void* metalNewWorker(int deviceIndex, const unsigned char* destTemplate, int counterOffset,
                      const char* prefix, int prefixLen, unsigned long batchSize);
 
 // Runs one batch starting at counterStart.
@@ -33,6 +33,41 @@ static inline uint32_t readBE32Host(const unsigned char* p) {
     return ((uint32_t)p[0] << 24) | ((uint32_t)p[1] << 16) | ((uint32_t)p[2] << 8) | (uint32_t)p[3];
 }
 
+static const uint32_t K_host[64] = {
+    0x428a2f98, 0x71374491, 0xb5c0fbcf, 0xe9b5dba5, 0x3956c25b, 0x59f111f1, 0x923f82a4, 0xab1c5ed5,
+    0xd807aa98, 0x12835b01, 0x243185be, 0x550c7dc3, 0x72be5d74, 0x80deb1fe, 0x9bdc06a7, 0xc19bf174,
+    0xe49b69c1, 0xefbe4786, 0x0fc19dc6, 0x240ca1cc, 0x2de92c6f, 0x4a7484aa, 0x5cb0a9dc, 0x76f988da,
+    0x983e5152, 0xa831c66d, 0xb00327c8, 0xbf597fc7, 0xc6e00bf3, 0xd5a79147, 0x06ca6351, 0x14292967,
+    0x27b70a85, 0x2e1b2138, 0x4d2c6dfc, 0x53380d13, 0x650a7354, 0x766a0abb, 0x81c2c92e, 0x92722c85,
+    0xa2bfe8a1, 0xa81a664b, 0xc24b8b70, 0xc76c51a3, 0xd192e819, 0xd6990624, 0xf40e3585, 0x106aa070,
+    0x19a4c116, 0x1e376c08, 0x2748774c, 0x34b0bcb5, 0x391c0cb3, 0x4ed8aa4a, 0x5b9cca4f, 0x682e6ff3,
+    0x748f82ee, 0x78a5636f, 0x84c87814, 0x8cc70208, 0x90befffa, 0xa4506ceb, 0xbef9a3f7, 0xc67178f2
+};
+
+// sha256CompressHost runs one SHA-256 compression of a 64-byte block into
+// state, for the midstate of the blocks before the counter.
+static void sha256CompressHost(uint32_t state[8], const unsigned char* block) {
+    uint32_t w[64];
+    for (int i = 0; i < 16; i++) {
+        w[i] = readBE32Host(block + i*4);
+    }
+    for (int i = 16; i < 64; i++) {
+        w[i] = sig1_host(w[i-2]) + w[i-7] + sig0_host(w[i-15]) + w[i-16];
+    }
+    uint32_t a = state[0], b = state[1], c = state[2], d = state[3];
+    uint32_t e = state[4], f = state[5], g = state[6], h = state[7];
+    for (int i = 0; i < 64; i++) {
+        uint32_t ep1 = rotr32_host(e, 6) ^ rotr32_host(e, 11) ^ rotr32_host(e, 25);
+        uint32_t ep0 = rotr32_host(a, 2) ^ rotr32_host(a, 13) ^ rotr32_host(a, 22);
+        uint32_t t1 = h + ep1 + ((e & f) ^ (~e & g)) + K_host[i] + w[i];
+        uint32_t t2 = ep0 + ((a & b) ^ (a & c) ^ (b & c));
+        h = g; g = f; f = e; e = d + t1;
+        d = c; c = b; b = a; a = t1 + t2;
+    }
+    state[0] += a; state[1] += b; state[2] += c; state[3] += d;
+    state[4] += e; state[5] += f; state[6] += g; state[7] += h;
+}
+
 // Embedded Metal shader source (SHA-256 + base32 prefix check)
 static NSString* const shaderSource = @"\n"
 "#include <metal_stdlib>\n"
@@ -78,7 +113,10 @@ static NSString* const shaderSource = @"\n"
 "    ulong counter_base;\n"
 "    uint prefix_len;\n"
 "    char prefix[64];\n"
-"    uint block0_words[14];\n"
+"    uint midstate[8];\n"
+"    uint block_words[16];\n"
+"    uint counter_word;\n"
+"    uint static_blocks;\n"
 "};\n"
 "\n"
 "kernel void vanity_search(\n"
@@ -93,21 +131,24 @@ static NSString* const shaderSource = @"\n"
 "    // Early exit if another thread already found a match\n"
 "    if (atomic_load_explicit(match_found, memory_order_relaxed) != 0) return;\n"
 "    \n"
-"    // SHA-256 compression\n"
-"    uint h0 = 0x6a09e667, h1 = 0xbb67ae85, h2 = 0x3c6ef372, h3 = 0xa54ff53a;\n"
-"    uint h4 = 0x510e527f, h5 = 0x9b05688c, h6 = 0x1f83d9ab, h7 = 0x5be0cd19;\n"
+"    // SHA-256 compression, resuming from the blocks before the counter\n"
+"    uint h0 = params->midstate[0], h1 = params->midstate[1];\n"
+"    uint h2 = params->midstate[2], h3 = params->midstate[3];\n"
+"    uint h4 = params->midstate[4], h5 = params->midstate[5];\n"
+"    uint h6 = params->midstate[6], h7 = params->midstate[7];\n"
 "    \n"
-"    // Block 0 has dynamic counter bytes and static words from params\n"
+"    // The counter block has dynamic counter bytes and static words from params\n"
 "    uint w[64];\n"
+"    for (uint i = 0; i < 16; i++) {\n"
+"        w[i] = params->block_words[i];\n"
+"    }\n"
 "    uint c0 = (uint)(counter & 0xFFFFFFFFUL);\n"
 "    uint c1 = (uint)(counter >> 32);\n"
-"    w[0] = ((c0 & 0x000000FFu) << 24) | ((c0 & 0x0000FF00u) << 8) |\n"
-"           ((c0 & 0x00FF0000u) >> 8)  | ((c0 & 0xFF000000u) >> 24);\n"
-"    w[1] = ((c1 & 0x000000FFu) << 24) | ((c1 & 0x0000FF00u) << 8) |\n"
-"           ((c1 & 0x00FF0000u) >> 8)  | ((c1 & 0xFF000000u) >> 24);\n"
-"    for (uint i = 0; i < 14; i++) {\n"
-"        w[i + 2] = params->block0_words[i];\n"
-"    }\n"
+"    uint cw = params->counter_word;\n"
+"    w[cw] = ((c0 & 0x000000FFu) << 24) | ((c0 & 0x0000FF00u) << 8) |\n"
+"            ((c0 & 0x00FF0000u) >> 8)  | ((c0 & 0xFF000000u) >> 24);\n"
+"    w[cw + 1] = ((c1 & 0x000000FFu) << 24) | ((c1 & 0x0000FF00u) << 8) |\n"
+"                ((c1 & 0x00FF0000u) >> 8)  | ((c1 & 0xFF000000u) >> 24);\n"
 "    for (uint i = 16; i < 64; i++) {\n"
 "        w[i] = sig1(w[i-2]) + w[i-7] + sig0(w[i-15]) + w[i-16];\n"
 "    }\n"
@@ -123,8 +164,8 @@ static NSString* const shaderSource = @"\n"
 "    h0 += a; h1 += b; h2 += c; h3 += d;\n"
 "    h4 += e; h5 += f; h6 += g; h7 += h;\n"
 "    \n"
-"    // Blocks 1..6 use pre-expanded static schedules from static_w\n"
-"    for (uint block = 0; block < 6; block++) {\n"
+"    // The blocks after it use pre-expanded static schedules from static_w\n"
+"    for (uint block = 0; block < params->static_blocks; block++) {\n"
 "        constant uint* ws = static_w + block * 64;\n"
 "        a = h0; b = h1; c = h2; d = h3;\n"
 "        e = h4; f = h5; g = h6; h = h7;\n"
@@ -199,7 +240,10 @@ typedef struct __attribute__((packed)) {
     uint64_t counter_base;
     uint32_t prefix_len;
     char prefix[64];
-    uint32_t block0_words[14];
+    uint32_t midstate[8];
+    uint32_t block_words[16];
+    uint32_t counter_word;
+    uint32_t static_blocks;
 } VanityParams;
 
 int metalAvailable(void) {
@@ -237,7 +281,7 @@ char** metalListDevices(int* count) {
     }
 }
 
-void* metalNewWorker(int deviceIndex, const unsigned char* destTemplate,
+void* metalNewWorker(int deviceIndex, const unsigned char* destTemplate, int counterOffset,
                      const char* prefix, int prefixLen, unsigned long batchSize) {
     @autoreleasepool {
         if (batchSize == 0) return NULL;
@@ -283,16 +327,32 @@ void* metalNewWorker(int deviceIndex, const unsigned char* destTemplate,
         params.prefix_len = (uint32_t)prefixLen;
         size_t prefixCopyLen = (size_t)(prefixLen < 64 ? prefixLen : 64);
         memcpy(params.prefix, prefix, prefixCopyLen);
-        for (int i = 0; i < 14; i++) {
-            params.block0_words[i] = readBE32Host(destTemplate + 8 + i*4);
+
+        // The destination spans 7 SHA-256 blocks; the counter lies in
+        // counterBlock. Hash the blocks before it once here.
+        int counterBlock = counterOffset / 64;
+        uint32_t state[8] = {
+            0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a,
+            0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19
+        };
+        for (int b = 0; b < counterBlock; b++) {
+            sha256CompressHost(state, destTemplate + (size_t)b*64);
+        }
+        memcpy(params.midstate, state, sizeof(state));
+        for (int i = 0; i < 16; i++) {
+            params.block_words[i] = readBE32Host(destTemplate + (size_t)counterBlock*64 + i*4);
         }
+        params.counter_word = (uint32_t)(counterOffset % 64) / 4;
+        params.static_blocks = (uint32_t)(6 - counterBlock);
 
         uint32_t staticW[6][64];
-        for (int b = 0; b < 6; b++) {
+        memset(staticW, 0, sizeof(staticW));
+        for (int b = 0; b < 6 - counterBlock; b++) {
+            int n = counterBlock + 1 + b; // block number in the destination
             unsigned char block[64];
             memset(block, 0, sizeof(block));
-            if (b < 5) {
-                memcpy(block, destTemplate + (size_t)(b+1)*64, 64);
+            if (n < 6) {
+                memcpy(block, destTemplate + (size_t)n*64, 64);
             } else {
                 memcpy(block, destTemplate + 384, 7);
                 block[7] = 0x80;
@@ -45,6 +45,9 @@ func NewWorker(cfg WorkerConfig) (*Worker, error) {
 	if !Available() {
 		return nil, fmt.Errorf("no Metal GPU available")
 	}
+	if err := checkCounterOffset(cfg.CounterOffset); err != nil {
+		return nil, err
+	}
 
 	cPrefix := C.CString(cfg.Prefix)
 	defer C.free(unsafe.Pointer(cPrefix))
@@ -52,6 +55,7 @@ func NewWorker(cfg WorkerConfig) (*Worker, error) {
 	handle := C.metalNewWorker(
 		C.int(cfg.DeviceIndex),
 		(*C.uchar)(unsafe.Pointer(&cfg.DestTemplate[0])),
+		C.int(cfg.CounterOffset),
 		cPrefix,
 		C.int(len(cfg.Prefix)),
 		C.ulong(cfg.BatchSize),
@@ -18,14 +18,15 @@ func TestMetalI2PBatchExactPrefixMatch(t *testing.T) {
 		t.Fatal(err)
 	}
 	cand := candAny.(*address.I2PCandidate)
-	cand.Dest.MutateEncryptionKey(0)
+	cand.Dest.MutatePadding(0)
 	fullPrefix := cand.Address()
 
 	worker, err := NewWorker(WorkerConfig{
-		DeviceIndex:  0,
-		DestTemplate: cand.Raw(),
-		Prefix:       fullPrefix,
-		BatchSize:    1,
+		DeviceIndex:   0,
+		DestTemplate:  cand.Raw(),
+		CounterOffset: cand.Dest.CounterOffset(),
+		Prefix:        fullPrefix,
+		BatchSize:     1,
 	})
 	if err != nil {
 		t.Fatal(err)
@@ -57,7 +58,7 @@ func TestMetalI2PBatchExactPrefixMismatch(t *testing.T) {
 		t.Fatal(err)
 	}
 	cand := candAny.(*address.I2PCandidate)
-	cand.Dest.MutateEncryptionKey(0)
+	cand.Dest.MutatePadding(0)
 	prefix := cand.Address()
 
 	last := prefix[len(prefix)-1]
@@ -69,10 +70,11 @@ func TestMetalI2PBatchExactPrefixMismatch(t *testing.T) {
 	prefix = prefix[:len(prefix)-1] + string(last)
 
 	worker, err := NewWorker(WorkerConfig{
-		DeviceIndex:  0,
-		DestTemplate: cand.Raw(),
-		Prefix:       prefix,
-		BatchSize:    1,
+		DeviceIndex:   0,
+		DestTemplate:  cand.Raw(),
+		CounterOffset: cand.Dest.CounterOffset(),
+		Prefix:        prefix,
+		BatchSize:     1,
 	})
 	if err != nil {
 		t.Fatal(err)
@@ -90,3 +92,42 @@ func TestMetalI2PBatchExactPrefixMismatch(t *testing.T) {
 		t.Fatalf("expected checked=1, got %d", result.Checked)
 	}
 }
+
+// TestMetalI2PCounterOffset checks that the kernel writes the counter where
+// MutatePadding does for both destination types: in the first SHA-256 block
+// for X25519 and in a later one for ElGamal.
+func TestMetalI2PCounterOffset(t *testing.T) {
+	if !Available() {
+		t.Skip("Metal GPU not available")
+	}
+
+	for _, scheme := range []address.I2PScheme{{}, {X25519: true}} {
+		candAny, err := scheme.NewCandidate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		cand := candAny.(*address.I2PCandidate)
+		template := cand.Raw()
+		const target = 777
+		cand.Dest.MutatePadding(target)
+
+		worker, err := NewWorker(WorkerConfig{
+			DeviceIndex:   0,
+			DestTemplate:  template,
+			CounterOffset: cand.Dest.CounterOffset(),
+			Prefix:        cand.Address(),
+			BatchSize:     1024,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := worker.RunBatch(0)
+		worker.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Found || result.MatchCounter != target {
+			t.Errorf("X25519=%v: got %+v, want counter %d", scheme.X25519, result, target)
+		}
+	}
+}
@@ -0,0 +1,492 @@
+// Package jobs runs a list of vanity searches from a JSON job file, one after
+// another, recording a result for each. Jobs whose output already exists are
+// skipped, so an interrupted batch can simply be run again.
+//
+// A job file looks like:
+//
+//	{
+//	  "defaults": {"network": "torv3", "cores": 8, "gpu": true, "timeout": "2h"},
+//	  "jobs": [
+//	    {"name": "web", "prefix": "web", "output": "keys/web"},
+//	    {"name": "mail", "network": "i2p", "prefix": "mail", "output": "keys/mail.dat", "max_attempts": 5000000000}
+//	  ]
+//	}
+//
+// Fields left out of a job are taken from "defaults". Relative output paths
+// are resolved against the directory containing the job file. I2P jobs may
+// set "crypto": "x25519" for an X25519 encryption key.
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/address"
+	"github.com/go-i2p/i2p-vanitygen/internal/base32check"
+	"github.com/go-i2p/i2p-vanitygen/internal/blocklist"
+	"github.com/go-i2p/i2p-vanitygen/internal/expr"
+	"github.com/go-i2p/i2p-vanitygen/internal/generator"
+	"github.com/go-i2p/i2p-vanitygen/internal/gpu"
+	"github.com/go-i2p/i2p-vanitygen/internal/keyfile"
+)
+
+// Duration is a time.Duration that reads and writes strings such as "90m".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"90m\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Job describes one search.
+type Job struct {
+	Name    string `json:"name,omitempty"`
+	Network string `json:"network,omitempty"`
+	// Crypto is the I2P encryption key type, "elgamal" (the default) or
+	// "x25519".
+	Crypto string `json:"crypto,omitempty"`
+	Prefix string `json:"prefix,omitempty"`
+	Suffix string `json:"suffix,omitempty"`
+	// Regex replaces Prefix and Suffix with an expression (see package dfa).
+	Regex string `json:"regex,omitempty"`
+	// Contains replaces Prefix and Suffix with a word that may occur anywhere.
+	Contains string `json:"contains,omitempty"`
+	// Expr replaces Prefix and Suffix with an expression combining several
+	// conditions (see package expr).
+	Expr string `json:"expr,omitempty"`
+	// NoBlocklist allows matches containing a word of the built-in
+	// blocklist (see package blocklist), which are skipped by default.
+	NoBlocklist bool `json:"no_blocklist,omitempty"`
+	// Output is the .dat file (I2P) or hidden service directory (Tor) to write.
+	Output string `json:"output,omitempty"`
+	// Cores, GPU and GPUDevice are pointers so that an explicit 0 or false
+	// overrides a default. Cores 0 runs a job on the GPU only.
+	Cores     *int  `json:"cores,omitempty"`
+	GPU       *bool `json:"gpu,omitempty"`
+	GPUDevice *int  `json:"gpu_device,omitempty"`
+	// Timeout and MaxAttempts bound the search; zero means unlimited.
+	Timeout     Duration `json:"timeout,omitempty"`
+	MaxAttempts uint64   `json:"max_attempts,omitempty"`
+}
+
+// File is the top-level job file document.
+type File struct {
+	Defaults Job   `json:"defaults"`
+	Jobs     []Job `json:"jobs"`
+}
+
+// Load reads a job file, applies defaults and validates every job.
+func Load(path string) ([]Job, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f File
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return f.resolve(filepath.Dir(path))
+}
+
+func (f *File) resolve(baseDir string) ([]Job, error) {
+	if len(f.Jobs) == 0 {
+		return nil, fmt.Errorf("job file contains no jobs")
+	}
+	outputs := make(map[string]int)
+	jobs := make([]Job, len(f.Jobs))
+	for i, j := range f.Jobs {
+		if j.Name == "" {
+			j.Name = fmt.Sprintf("#%d", i+1)
+		}
+		j, err := Resolve(j, f.Defaults, baseDir)
+		if err != nil {
+			return nil, err
+		}
+		if prev, ok := outputs[j.Output]; ok {
+			return nil, fmt.Errorf("job %s: output %s is also used by job %s", j.Name, j.Output, jobs[prev].Name)
+		}
+		outputs[j.Output] = i
+		jobs[i] = j
+	}
+	return jobs, nil
+}
+
+// Resolve fills the unset fields of j from d, resolves a relative output
+// against baseDir and validates the result.
+func Resolve(j, d Job, baseDir string) (Job, error) {
+	if j.Network == "" {
+		j.Network = d.Network
+	}
+	if j.Network == "" {
+		j.Network = "i2p"
+	}
+	if j.Crypto == "" {
+		j.Crypto = d.Crypto
+	}
+	if j.Cores == nil {
+		j.Cores = d.Cores
+	}
+	if j.Cores == nil {
+		n := runtime.NumCPU()
+		j.Cores = &n
+	}
+	if j.GPU == nil {
+		j.GPU = d.GPU
+	}
+	if j.GPUDevice == nil {
+		j.GPUDevice = d.GPUDevice
+	}
+	if j.Timeout == 0 {
+		j.Timeout = d.Timeout
+	}
+	if j.MaxAttempts == 0 {
+		j.MaxAttempts = d.MaxAttempts
+	}
+	j.NoBlocklist = j.NoBlocklist || d.NoBlocklist
+
+	scheme, err := j.scheme()
+	if err != nil {
+		return j, fmt.Errorf("job %s: %w", j.Name, err)
+	}
+	switch {
+	case j.Regex != "" && j.Contains != "", j.Expr != "" && (j.Regex != "" || j.Contains != ""):
+		return j, fmt.Errorf("job %s: only one of regex, contains and expr can be set", j.Name)
+	case (j.Regex != "" || j.Contains != "" || j.Expr != "") && (j.Prefix != "" || j.Suffix != ""):
+		return j, fmt.Errorf("job %s: regex, contains and expr cannot be combined with prefix or suffix", j.Name)
+	case j.Expr != "":
+		e, err := expr.Compile(j.Expr)
+		if err == nil {
+			err = e.Validate(scheme)
+		}
+		if err != nil {
+			return j, fmt.Errorf("job %s: invalid expr: %w", j.Name, err)
+		}
+	case j.Regex != "":
+		if err := address.ValidateRegex(scheme, j.Regex); err != nil {
+			return j, fmt.Errorf("job %s: invalid regex: %w", j.Name, err)
+		}
+	case j.Contains != "":
+		if err := address.ValidateContains(scheme, j.Contains); err != nil {
+			return j, fmt.Errorf("job %s: %w", j.Name, err)
+		}
+		if b := j.blocklist(); b != nil && b.PassProbability(scheme, j.Contains, "") == 0 {
+			return j, fmt.Errorf("job %s: %q contains a blocked word (see no_blocklist)", j.Name, j.Contains)
+		}
+		j.Contains = strings.ToLower(j.Contains)
+	default:
+		if err := address.ValidateSearch(scheme, j.Prefix, j.Suffix); err != nil {
+			return j, fmt.Errorf("job %s: %w", j.Name, err)
+		}
+		if b := j.blocklist(); b != nil && b.PassProbability(scheme, j.Prefix, j.Suffix) == 0 {
+			return j, fmt.Errorf("job %s: every match contains a blocked word (see no_blocklist)", j.Name)
+		}
+	}
+	j.Prefix = strings.ToLower(j.Prefix)
+	j.Suffix = strings.ToLower(j.Suffix)
+	if j.Output == "" {
+		return j, fmt.Errorf("job %s: output is required", j.Name)
+	}
+	if !filepath.IsAbs(j.Output) {
+		j.Output = filepath.Join(baseDir, j.Output)
+	}
+	j.Output = filepath.Clean(j.Output)
+	if *j.Cores < 0 || *j.Cores > runtime.NumCPU() {
+		return j, fmt.Errorf("job %s: cores must be between 0 and %d", j.Name, runtime.NumCPU())
+	}
+	if *j.Cores == 0 && (j.GPU == nil || !*j.GPU) {
+		return j, fmt.Errorf("job %s: nothing to search with (cores is 0 and gpu is off)", j.Name)
+	}
+	if *j.Cores == 0 && !gpu.Available() {
+		return j, fmt.Errorf("job %s: nothing to search with (cores is 0 and no GPU is available)", j.Name)
+	}
+	if *j.Cores == 0 && (j.Suffix != "" || j.Regex != "" || j.Contains != "" || j.Expr != "" || !base32check.IsLiteral(j.Prefix)) {
+		return j, fmt.Errorf("job %s: only plain prefix searches run on the GPU, so cores cannot be 0", j.Name)
+	}
+	return j, nil
+}
+
+// EstimatedAttempts returns the average number of attempts j needs, or 0 if
+// its network or crypto is unknown.
+func (j Job) EstimatedAttempts() float64 {
+	scheme, err := j.scheme()
+	if err != nil {
+		return 0
+	}
+	var attempts float64
+	prefix, suffix := j.Prefix, j.Suffix
+	switch {
+	case j.Regex != "":
+		attempts, prefix = address.EstimateRegexAttempts(scheme, j.Regex), ""
+	case j.Contains != "":
+		attempts, prefix = address.EstimateContainsAttempts(scheme, j.Contains), ""
+	case j.Expr != "":
+		e, err := expr.Compile(j.Expr)
+		if err != nil {
+			return 0
+		}
+		attempts, prefix = e.EstimateAttempts(scheme), ""
+	default:
+		attempts = address.EstimateSearchAttempts(scheme, prefix, suffix)
+	}
+	if b := j.blocklist(); b != nil {
+		// Regex, contains and expr matches can be anywhere, so they are
+		// assumed to be blocked as often as any address.
+		attempts = b.AdjustAttempts(scheme, prefix, suffix, attempts)
+	}
+	return attempts
+}
+
+// scheme returns the Scheme for j's network and crypto.
+func (j Job) scheme() (address.Scheme, error) {
+	scheme, err := address.LookupScheme(j.Network)
+	if err != nil {
+		return nil, err
+	}
+	switch j.Crypto {
+	case "", "elgamal":
+		return scheme, nil
+	case "x25519":
+		if _, ok := scheme.(address.I2PScheme); !ok {
+			return nil, fmt.Errorf("crypto applies only to network i2p")
+		}
+		return address.I2PScheme{X25519: true}, nil
+	}
+	return nil, fmt.Errorf("unknown crypto %q (allowed: elgamal, x25519)", j.Crypto)
+}
+
+// blocklist returns the words j's matches must not contain, or nil.
+func (j Job) blocklist() *blocklist.Blocklist {
+	if j.NoBlocklist {
+		return nil
+	}
+	return blocklist.Default()
+}
+
+// Status is the outcome of one job.
+type Status string
+
+const (
+	StatusFound       Status = "found"
+	StatusSkipped     Status = "skipped"     // output already existed
+	StatusNotFound    Status = "not_found"   // timeout or attempt limit reached
+	StatusFailed      Status = "failed"      // could not run or save
+	StatusInterrupted Status = "interrupted" // batch was cancelled
+)
+
+// Result records what happened to one job.
+type Result struct {
+	Name        string    `json:"name"`
+	Network     string    `json:"network"`
+	Prefix      string    `json:"prefix"`
+	Suffix      string    `json:"suffix,omitempty"`
+	Regex       string    `json:"regex,omitempty"`
+	Contains    string    `json:"contains,omitempty"`
+	Expr        string    `json:"expr,omitempty"`
+	Output      string    `json:"output"`
+	Status      Status    `json:"status"`
+	Address     string    `json:"address,omitempty"`
+	Attempts    uint64    `json:"attempts,omitempty"`
+	DurationSec float64   `json:"duration_sec,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Finished    time.Time `json:"finished"`
+}
+
+// Runner executes jobs sequentially.
+type Runner struct {
+	// OnStart is called before each job that is not skipped.
+	OnStart func(job Job)
+	// OnStats is called for every generator.Stats tick of the running job.
+	OnStats func(job Job, st generator.Stats)
+	// OnResult is called after every job, including skipped ones.
+	OnResult func(r Result)
+}
+
+// Run executes the jobs in order. Once ctx is cancelled the running job is
+// stopped and it and all remaining jobs are reported as interrupted.
+func (rn *Runner) Run(ctx context.Context, jobs []Job) []Result {
+	results := make([]Result, 0, len(jobs))
+	for _, job := range jobs {
+		var r Result
+		switch {
+		case ctx.Err() != nil:
+			r = newResult(job, StatusInterrupted)
+		case outputExists(job):
+			r = skippedResult(job)
+		default:
+			if rn.OnStart != nil {
+				rn.OnStart(job)
+			}
+			r = rn.runJob(ctx, job)
+		}
+		r.Finished = time.Now().UTC()
+		results = append(results, r)
+		if rn.OnResult != nil {
+			rn.OnResult(r)
+		}
+	}
+	return results
+}
+
+func newResult(job Job, status Status) Result {
+	return Result{Name: job.Name, Network: job.Network, Prefix: job.Prefix, Suffix: job.Suffix, Regex: job.Regex, Contains: job.Contains, Expr: job.Expr, Output: job.Output, Status: status}
+}
+
+func (rn *Runner) runJob(ctx context.Context, job Job) Result {
+	scheme, err := job.scheme()
+	if err != nil {
+		r := newResult(job, StatusFailed)
+		r.Error = err.Error()
+		return r
+	}
+
+	jobCtx := ctx
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		jobCtx, cancel = context.WithTimeout(ctx, time.Duration(job.Timeout))
+		defer cancel()
+	}
+
+	var reject address.Matcher
+	if b := job.blocklist(); b != nil {
+		reject = b
+	}
+	gen, err := generator.NewWithConfig(generator.Config{
+		Scheme:    scheme,
+		Prefix:    job.Prefix,
+		Suffix:    job.Suffix,
+		Regex:     job.Regex,
+		Contains:  job.Contains,
+		Expr:      job.Expr,
+		Reject:    reject,
+		Cores:     deref(job.Cores),
+		GPU:       job.GPU != nil && *job.GPU,
+		GPUDevice: deref(job.GPUDevice),
+	})
+	if err != nil {
+		r := newResult(job, StatusFailed)
+		r.Error = err.Error()
+		return r
+	}
+	resultCh, statsCh := gen.Start(jobCtx)
+
+	var (
+		found   *generator.Result
+		checked uint64
+	)
+	for resultCh != nil || statsCh != nil {
+		select {
+		case res, ok := <-resultCh:
+			if !ok {
+				resultCh = nil
+				continue
+			}
+			found = &res
+		case st, ok := <-statsCh:
+			if !ok {
+				statsCh = nil
+				continue
+			}
+			checked = st.Checked
+			if rn.OnStats != nil {
+				rn.OnStats(job, st)
+			}
+			if job.MaxAttempts > 0 && st.Checked >= job.MaxAttempts {
+				gen.Stop()
+			}
+		}
+	}
+
+	if found == nil {
+		r := newResult(job, StatusNotFound)
+		if ctx.Err() != nil {
+			r.Status = StatusInterrupted
+		}
+		r.Attempts = checked
+		return r
+	}
+
+	r := newResult(job, StatusFound)
+	r.Address = found.Address
+	r.Attempts = found.Attempts
+	r.DurationSec = found.Duration.Seconds()
+	if err := saveAtomic(found.Candidate, job.Output); err != nil {
+		r.Status = StatusFailed
+		r.Error = "saving keys: " + err.Error()
+	}
+	return r
+}
+
+// deref returns *p, or 0 for a field that Resolve has not filled in.
+func deref(p *int) int {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
+
+// skippedResult records a job whose output already exists, reading its
+// address back from the saved keys.
+func skippedResult(job Job) Result {
+	r := newResult(job, StatusSkipped)
+	infos, err := keyfile.Inspect(job.Output)
+	switch {
+	case err != nil:
+		r.Error = "reading existing output: " + err.Error()
+	case len(infos) != 1 || !infos[0].OK():
+		r.Error = "existing output is not a valid key set (check it with the inspect command)"
+	default:
+		r.Address = infos[0].Address
+	}
+	return r
+}
+
+// outputExists reports whether a previous run already produced the job's keys.
+func outputExists(job Job) bool {
+	_, err := os.Stat(job.Output)
+	return err == nil
+}
+
+// saveAtomic writes the keys next to path and renames them into place, so an
+// interrupted save never leaves a partial output that a re-run would skip.
+func saveAtomic(cand address.Candidate, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp := path + ".partial"
+	os.RemoveAll(tmp)
+	if err := cand.SaveKeys(tmp); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// SaveResults writes the results as indented JSON.
+func SaveResults(path string, results []Result) error {
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
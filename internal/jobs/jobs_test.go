@@ -0,0 +1,156 @@
+package jobs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/destination"
+	"github.com/go-i2p/i2p-vanitygen/internal/gpu"
+)
+
+func writeJobFile(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, "jobs.json")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadAppliesDefaults(t *testing.T) {
+	dir := t.TempDir()
+	path := writeJobFile(t, dir, `{
+		"defaults": {"network": "torv3", "cores": 1, "gpu_device": 2, "timeout": "90m"},
+		"jobs": [
+			{"prefix": "ABC", "output": "a"},
+			{"name": "b", "network": "i2p", "prefix": "b", "output": "/abs/b.dat", "timeout": "1s", "gpu_device": 0}
+		]
+	}`)
+	list, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := list[0], list[1]
+	if a.Name != "#1" || a.Network != "torv3" || a.Prefix != "abc" || *a.Cores != 1 || *a.GPUDevice != 2 {
+		t.Errorf("defaults not applied: %+v", a)
+	}
+	if a.Output != filepath.Join(dir, "a") {
+		t.Errorf("relative output should resolve against the job file: %s", a.Output)
+	}
+	if b.Network != "i2p" || b.Output != "/abs/b.dat" || time.Duration(b.Timeout) != time.Second || *b.GPUDevice != 0 {
+		t.Errorf("job fields should override defaults: %+v", b)
+	}
+}
+
+func TestLoadRejectsInvalidJobs(t *testing.T) {
+	tests := map[string]string{
+		"empty":      `{"jobs": []}`,
+		"network":    `{"jobs": [{"network": "tor2", "prefix": "a", "output": "x"}]}`,
+		"prefix":     `{"jobs": [{"prefix": "a1", "output": "x"}]}`,
+		"output":     `{"jobs": [{"prefix": "a"}]}`,
+		"duplicate":  `{"jobs": [{"prefix": "a", "output": "x"}, {"prefix": "b", "output": "./x"}]}`,
+		"unknown":    `{"jobs": [{"prefix": "a", "output": "x", "prefx": "b"}]}`,
+		"timeout":    `{"jobs": [{"prefix": "a", "output": "x", "timeout": 5}]}`,
+		"regex":      `{"jobs": [{"regex": "^a(", "output": "x"}]}`,
+		"regex+pre":  `{"jobs": [{"regex": "^ab", "prefix": "a", "output": "x"}]}`,
+		"blocked":    `{"jobs": [{"prefix": "porn", "output": "x"}]}`,
+		"expr":       `{"jobs": [{"expr": "prefix(\"a1\")", "output": "x"}]}`,
+		"expr+re":    `{"jobs": [{"expr": "prefix(\"a\")", "regex": "^ab", "output": "x"}]}`,
+		"crypto":     `{"jobs": [{"crypto": "rsa", "prefix": "a", "output": "x"}]}`,
+		"no cores":   `{"jobs": [{"cores": 0, "prefix": "a", "output": "x"}]}`,
+		"tor+x25519": `{"jobs": [{"network": "torv3", "crypto": "x25519", "prefix": "a", "output": "x"}]}`,
+	}
+	if !gpu.Available() {
+		tests["no gpu"] = `{"jobs": [{"cores": 0, "gpu": true, "prefix": "a", "output": "x"}]}`
+	}
+	for name, content := range tests {
+		if _, err := Load(writeJobFile(t, t.TempDir(), content)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestRunIsIdempotent(t *testing.T) {
+	dir := t.TempDir()
+	path := writeJobFile(t, dir, `{
+		"defaults": {"cores": 1},
+		"jobs": [
+			{"name": "i2p", "prefix": "a", "output": "keys/a.dat"},
+			{"name": "tor", "network": "torv3", "prefix": "a", "output": "keys/a"},
+			{"name": "limit", "prefix": "zzzzzzzz", "output": "keys/z.dat", "max_attempts": 1000}
+		]
+	}`)
+	list, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rn Runner
+	first := rn.Run(context.Background(), list)
+	want := []Status{StatusFound, StatusFound, StatusNotFound}
+	for i, r := range first {
+		if r.Status != want[i] {
+			t.Errorf("first run, job %s: status %s (%s), want %s", r.Name, r.Status, r.Error, want[i])
+		}
+	}
+
+	second := rn.Run(context.Background(), list)
+	want = []Status{StatusSkipped, StatusSkipped, StatusNotFound}
+	for i, r := range second {
+		if r.Status != want[i] {
+			t.Errorf("second run, job %s: status %s, want %s", r.Name, r.Status, want[i])
+		}
+		if r.Status == StatusSkipped && r.Address != first[i].Address {
+			t.Errorf("skipped job %s should report the saved address %s, got %s", r.Name, first[i].Address, r.Address)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, r := range rn.Run(ctx, list) {
+		if r.Status != StatusInterrupted {
+			t.Errorf("cancelled run, job %s: status %s", r.Name, r.Status)
+		}
+	}
+}
+
+func TestResultRecordsMatcher(t *testing.T) {
+	path := writeJobFile(t, t.TempDir(), `{"jobs": [{"expr": "prefix(\"ab\") || prefix(\"cd\")", "output": "x.dat"}]}`)
+	list, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var rn Runner
+	r := rn.Run(ctx, list)[0]
+	if r.Expr != list[0].Expr {
+		t.Errorf("result expr %q, want %q", r.Expr, list[0].Expr)
+	}
+}
+
+func TestRunX25519(t *testing.T) {
+	dir := t.TempDir()
+	path := writeJobFile(t, dir, `{
+		"defaults": {"cores": 1, "crypto": "x25519"},
+		"jobs": [{"prefix": "a", "output": "a.dat"}]
+	}`)
+	list, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rn Runner
+	if r := rn.Run(context.Background(), list)[0]; r.Status != StatusFound {
+		t.Fatalf("status %s (%s)", r.Status, r.Error)
+	}
+	d, err := destination.LoadKeys(list[0].Output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.CryptoType() != destination.CryptoTypeX25519 {
+		t.Errorf("crypto type %d, want %d", d.CryptoType(), destination.CryptoTypeX25519)
+	}
+}
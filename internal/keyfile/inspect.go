@@ -0,0 +1,210 @@
+// Package keyfile reads back and audits key material written by this tool:
+// I2P .dat files from destination.Destination.SaveKeys and Tor hidden service
+// directories from address.TorV3Candidate.SaveKeys.
+package keyfile
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/address"
+	"github.com/go-i2p/i2p-vanitygen/internal/destination"
+)
+
+// Tor hidden service file names.
+const (
+	TorSecretKeyFile = "hs_ed25519_secret_key"
+	TorPublicKeyFile = "hs_ed25519_public_key"
+	TorHostnameFile  = "hostname"
+)
+
+// Info describes one set of key material and any inconsistencies found in it.
+type Info struct {
+	Path    string `json:"path"`
+	Network string `json:"network"`
+	// Address is the full address derived from the private key.
+	Address string `json:"address,omitempty"`
+	// Destination is the I2P base64 destination (I2P only).
+	Destination string `json:"destination,omitempty"`
+	// Problems lists every structural or consistency check that failed.
+	Problems []string `json:"problems,omitempty"`
+}
+
+// OK reports whether no problems were found.
+func (info *Info) OK() bool { return len(info.Problems) == 0 }
+
+func (info *Info) problemf(format string, args ...any) {
+	info.Problems = append(info.Problems, fmt.Sprintf(format, args...))
+}
+
+// Inspect audits the key material at path. A file is read as an I2P key
+// file and a directory containing hs_ed25519_secret_key as a Tor hidden
+// service directory. Any other directory is scanned one level deep for
+// .dat files and hidden service directories, returning one Info for each.
+func Inspect(path string) ([]*Info, error) {
+	st, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !st.IsDir() {
+		info, err := InspectI2P(path)
+		if err != nil {
+			return nil, err
+		}
+		return []*Info{info}, nil
+	}
+	if isTorDir(path) {
+		info, err := InspectTorV3(path)
+		if err != nil {
+			return nil, err
+		}
+		return []*Info{info}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var infos []*Info
+	for _, e := range entries {
+		p := filepath.Join(path, e.Name())
+		var info *Info
+		switch {
+		case e.IsDir() && isTorDir(p):
+			info, err = InspectTorV3(p)
+		case !e.IsDir() && strings.HasSuffix(e.Name(), ".dat"):
+			info, err = InspectI2P(p)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	if len(infos) == 0 {
+		return nil, fmt.Errorf("%s: no key files found", path)
+	}
+	return infos, nil
+}
+
+func isTorDir(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, TorSecretKeyFile))
+	return err == nil
+}
+
+// InspectI2P audits an I2P key file: 679 bytes for ElGamal, 455 for X25519.
+func InspectI2P(path string) (*Info, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	info := &Info{Path: path, Network: address.NetworkI2P.String()}
+
+	d, err := destination.ParseKeys(data)
+	if err != nil {
+		info.problemf("%v", err)
+		if len(data) < destination.DestinationSize {
+			return info, nil
+		}
+		// Still report the address of whatever destination is present.
+		d = &destination.Destination{}
+		copy(d.Raw[:], data)
+	}
+	info.Address = d.FullB32Address()
+	info.Destination = d.Base64()
+
+	cert := d.Raw[destination.EncryptionKeySize+destination.SigningKeySize:]
+	if cert[0] != destination.CertTypeKeyCert {
+		info.problemf("certificate type is %d, want %d (key certificate)", cert[0], destination.CertTypeKeyCert)
+	}
+	if n := binary.BigEndian.Uint16(cert[1:3]); n != destination.CertPayloadLength {
+		info.problemf("certificate length is %d, want %d", n, destination.CertPayloadLength)
+	}
+	if t := binary.BigEndian.Uint16(cert[3:5]); t != destination.SigTypeEdDSASHA512Ed25519 {
+		info.problemf("signing key type is %d, want %d (EdDSA-SHA512-Ed25519)", t, destination.SigTypeEdDSASHA512Ed25519)
+	}
+	t := binary.BigEndian.Uint16(cert[5:7])
+	if t != destination.CryptoTypeElGamal && t != destination.CryptoTypeX25519 {
+		info.problemf("crypto type is %d, want %d (ElGamal) or %d (X25519)", t, destination.CryptoTypeElGamal, destination.CryptoTypeX25519)
+	}
+
+	if d.SigningPrivateKey != nil {
+		derived := d.SigningPrivateKey.Public().(ed25519.PublicKey)
+		if !derived.Equal(d.SigningPublicKey()) {
+			info.problemf("Ed25519 seed does not match the signing public key in the destination")
+		}
+		if t == destination.CryptoTypeX25519 {
+			if err := d.CheckX25519(); err != nil {
+				info.problemf("%v", err)
+			}
+		}
+	}
+	return info, nil
+}
+
+// InspectTorV3 audits a Tor hidden service directory.
+func InspectTorV3(dir string) (*Info, error) {
+	info := &Info{Path: dir, Network: address.NetworkTorV3.String()}
+
+	secret, err := os.ReadFile(filepath.Join(dir, TorSecretKeyFile))
+	if err != nil {
+		return nil, err
+	}
+	var derived []byte
+	hdrLen := len(address.TorV3SecretKeyHeader)
+	if len(secret) != hdrLen+64 {
+		info.problemf("%s is %d bytes, want %d", TorSecretKeyFile, len(secret), hdrLen+64)
+	} else {
+		if !bytes.HasPrefix(secret, []byte(address.TorV3SecretKeyHeader)) {
+			info.problemf("%s has header %q, want %q", TorSecretKeyFile, headerText(secret), headerText([]byte(address.TorV3SecretKeyHeader)))
+		}
+		cand, err := address.TorV3CandidateFromExpanded(secret[hdrLen:])
+		if err != nil {
+			info.problemf("%s: %v", TorSecretKeyFile, err)
+		} else {
+			derived = cand.PublicKeyBytes()
+			info.Address = cand.FullAddress()
+		}
+	}
+
+	public, err := os.ReadFile(filepath.Join(dir, TorPublicKeyFile))
+	switch {
+	case err != nil:
+		info.problemf("%s: %v", TorPublicKeyFile, err)
+	case len(public) != len(address.TorV3PublicKeyHeader)+32:
+		info.problemf("%s is %d bytes, want %d", TorPublicKeyFile, len(public), len(address.TorV3PublicKeyHeader)+32)
+	default:
+		if !bytes.HasPrefix(public, []byte(address.TorV3PublicKeyHeader)) {
+			info.problemf("%s has header %q, want %q", TorPublicKeyFile, headerText(public), headerText([]byte(address.TorV3PublicKeyHeader)))
+		}
+		pub := public[len(address.TorV3PublicKeyHeader):]
+		if derived == nil {
+			info.Address = address.OnionAddress(pub) + ".onion"
+		} else if !bytes.Equal(pub, derived) {
+			info.problemf("secret scalar does not match %s (%s.onion)", TorPublicKeyFile, address.OnionAddress(pub))
+		}
+	}
+
+	hostname, err := os.ReadFile(filepath.Join(dir, TorHostnameFile))
+	switch {
+	case err != nil:
+		info.problemf("%s: %v", TorHostnameFile, err)
+	case info.Address != "" && strings.TrimSpace(string(hostname)) != info.Address:
+		info.problemf("%s is %q, want %q", TorHostnameFile, strings.TrimSpace(string(hostname)), info.Address)
+	}
+	return info, nil
+}
+
+// headerText returns the printable part of a 32-byte key file header.
+func headerText(b []byte) string {
+	if len(b) > 32 {
+		b = b[:32]
+	}
+	return strings.TrimRight(string(b), "\x00")
+}
@@ -0,0 +1,120 @@
+package keyfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/address"
+	"github.com/go-i2p/i2p-vanitygen/internal/destination"
+)
+
+func saveCandidate(t *testing.T, scheme address.Scheme, path string) address.Candidate {
+	t.Helper()
+	cand, err := scheme.NewCandidate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cand.SaveKeys(path); err != nil {
+		t.Fatal(err)
+	}
+	return cand
+}
+
+func TestInspectValidKeys(t *testing.T) {
+	dir := t.TempDir()
+	i2p := saveCandidate(t, address.I2PScheme{}, filepath.Join(dir, "vanity_a.dat"))
+	tor, err := address.NewTorV3Candidate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tor.AdvanceBy(12345) // scalar no longer matches the seed-derived one
+	if err := tor.SaveKeys(filepath.Join(dir, "vanity_b")); err != nil {
+		t.Fatal(err)
+	}
+
+	infos, err := Inspect(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("expected 2 key sets, got %d", len(infos))
+	}
+	want := map[string]string{"i2p": i2p.FullAddress(), "torv3": tor.FullAddress()}
+	for _, info := range infos {
+		if !info.OK() {
+			t.Errorf("%s: unexpected problems %v", info.Path, info.Problems)
+		}
+		if info.Address != want[info.Network] {
+			t.Errorf("%s: address %s, want %s", info.Path, info.Address, want[info.Network])
+		}
+	}
+}
+
+func TestInspectI2PDetectsCorruption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "k.dat")
+	saveCandidate(t, address.I2PScheme{}, path)
+	data, _ := os.ReadFile(path)
+	data[384] = 0          // certificate type
+	data[len(data)-1] ^= 1 // seed
+	os.WriteFile(path, data, 0600)
+
+	info, err := InspectI2P(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	joined := strings.Join(info.Problems, "\n")
+	for _, want := range []string{"certificate type", "seed does not match"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected a %q problem, got %v", want, info.Problems)
+		}
+	}
+
+	os.WriteFile(path, data[:500], 0600)
+	info, _ = InspectI2P(path)
+	if info.OK() || info.Address == "" {
+		t.Errorf("truncated file should report a problem but still an address: %+v", info)
+	}
+}
+
+func TestInspectTorV3DetectsCorruption(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "hs")
+	saveCandidate(t, address.TorV3Scheme{}, dir)
+
+	other, _ := address.NewTorV3Candidate()
+	pub := append([]byte(address.TorV3PublicKeyHeader), other.PublicKeyBytes()...)
+	os.WriteFile(filepath.Join(dir, TorPublicKeyFile), pub, 0600)
+	os.WriteFile(filepath.Join(dir, TorHostnameFile), []byte("wrong.onion\n"), 0600)
+	secret, _ := os.ReadFile(filepath.Join(dir, TorSecretKeyFile))
+	secret[3] = 'X'
+	os.WriteFile(filepath.Join(dir, TorSecretKeyFile), secret, 0600)
+
+	info, err := InspectTorV3(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Problems) != 3 {
+		t.Errorf("expected header, public key and hostname problems, got %v", info.Problems)
+	}
+}
+
+func TestInspectI2PX25519(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "k.dat")
+	cand := saveCandidate(t, address.I2PScheme{X25519: true}, path)
+	info, err := InspectI2P(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.OK() || info.Address != cand.FullAddress() {
+		t.Fatalf("valid X25519 keys: %+v", info)
+	}
+
+	data, _ := os.ReadFile(path)
+	data[destination.DestinationSize+1] ^= 1 // X25519 private key
+	os.WriteFile(path, data, 0600)
+	info, _ = InspectI2P(path)
+	if !strings.Contains(strings.Join(info.Problems, "\n"), "X25519 private key does not match") {
+		t.Errorf("expected an X25519 key problem, got %v", info.Problems)
+	}
+}
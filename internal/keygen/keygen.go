@@ -0,0 +1,124 @@
+// Package keygen writes batches of fresh, non-vanity identities, e.g. for
+// integration tests that need many distinct destinations or onion services.
+package keygen
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/address"
+)
+
+// IndexFile is the name of the index written next to the generated keys.
+const IndexFile = "index.json"
+
+// Entry maps one generated address to its key file or directory.
+type Entry struct {
+	Address string `json:"address"`
+	// Path is relative to the output directory so the set can be moved.
+	Path string `json:"path"`
+}
+
+// Index is the summary document written to IndexFile.
+type Index struct {
+	Network string    `json:"network"`
+	Created time.Time `json:"created"`
+	Keys    []Entry   `json:"keys"`
+}
+
+// Generate creates count identities with scheme.NewCandidate on workers
+// goroutines and saves each into dir, named after its address (.dat files
+// for I2P, hidden service directories for Tor). progress, if non-nil, is
+// called after every saved key with the number saved so far; it may be
+// called concurrently.
+//
+// On error or cancellation the entries saved before the failure are
+// returned together with the error.
+func Generate(ctx context.Context, scheme address.Scheme, dir string, count, workers int, progress func(done int)) ([]Entry, error) {
+	if count < 1 {
+		return nil, fmt.Errorf("count must be at least 1")
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > count {
+		workers = count
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("creating directory: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		next     atomic.Int64
+		done     atomic.Int64
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	entries := make([]Entry, count)
+	fail := func(err error) {
+		errOnce.Do(func() { firstErr = err })
+		cancel()
+	}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				i := int(next.Add(1)) - 1
+				if i >= count {
+					return
+				}
+				cand, err := scheme.NewCandidate()
+				if err != nil {
+					fail(fmt.Errorf("generating key: %w", err))
+					return
+				}
+				name := cand.Address()
+				if scheme.Network() == address.NetworkI2P {
+					name += ".dat"
+				}
+				if err := cand.SaveKeys(filepath.Join(dir, name)); err != nil {
+					fail(fmt.Errorf("saving keys for %s: %w", cand.FullAddress(), err))
+					return
+				}
+				entries[i] = Entry{Address: cand.FullAddress(), Path: name}
+				n := done.Add(1)
+				if progress != nil {
+					progress(int(n))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	saved := entries[:0]
+	for _, e := range entries {
+		if e.Address != "" {
+			saved = append(saved, e)
+		}
+	}
+	if firstErr != nil {
+		return saved, firstErr
+	}
+	return saved, ctx.Err()
+}
+
+// WriteIndex writes idx as indented JSON to path.
+func WriteIndex(path string, idx *Index) error {
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
@@ -0,0 +1,58 @@
+package keygen
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/address"
+	"github.com/go-i2p/i2p-vanitygen/internal/keyfile"
+)
+
+func TestGenerate(t *testing.T) {
+	for _, scheme := range []address.Scheme{address.I2PScheme{}, address.TorV3Scheme{}} {
+		t.Run(scheme.Network().String(), func(t *testing.T) {
+			dir := t.TempDir()
+			entries, err := Generate(context.Background(), scheme, dir, 6, 3, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 6 {
+				t.Fatalf("got %d entries, want 6", len(entries))
+			}
+			seen := make(map[string]bool)
+			for _, e := range entries {
+				if seen[e.Address] {
+					t.Errorf("duplicate address %s", e.Address)
+				}
+				seen[e.Address] = true
+
+				infos, err := keyfile.Inspect(filepath.Join(dir, e.Path))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(infos) != 1 || !infos[0].OK() || infos[0].Address != e.Address {
+					t.Errorf("%s: inspect = %+v", e.Path, infos)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	dir := t.TempDir()
+	entries, err := Generate(ctx, address.I2PScheme{}, dir, 100, 2, nil)
+	if err != context.Canceled {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("got %d entries after cancel", len(entries))
+	}
+	files, _ := os.ReadDir(dir)
+	if len(files) != 0 {
+		t.Errorf("%d files written after cancel", len(files))
+	}
+}
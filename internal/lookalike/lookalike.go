@@ -0,0 +1,116 @@
+// Package lookalike expands a desired word into base32 prefixes that look or
+// sound like it. Base32 has no 0, 1, 8 or 9, so "he11o" cannot be searched
+// for as typed, but "heiio", "heilo" and "hello" read much the same; and a
+// search for "hello" may as well accept "h3llo" or "heiio" too.
+//
+// The expansion is a single prefix pattern with a class at every position
+// that has alternatives, so one search covers every variant at once.
+package lookalike
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/base32check"
+)
+
+// alternatives lists, for each character a user may type, the base32
+// characters that can stand in for it. Digits missing from base32 map to
+// the letters they resemble; letters and digits that resemble each other
+// map both ways, as do the sound-alikes c and k.
+var alternatives = map[byte]string{
+	'0': "o",
+	'1': "il",
+	'8': "b",
+	'9': "gq",
+	'2': "2z",
+	'3': "3e",
+	'4': "4a",
+	'5': "5s",
+	'6': "6bg",
+	'7': "7t",
+	'a': "a4",
+	'b': "b6",
+	'c': "ck",
+	'e': "e3",
+	'g': "g6",
+	'i': "il",
+	'k': "kc",
+	'l': "li",
+	's': "s5",
+	't': "t7",
+	'u': "uv",
+	'v': "vu",
+	'z': "z2",
+}
+
+// Expansion is the set of lookalike prefixes of a word.
+type Expansion struct {
+	// Pattern matches every variant, e.g. "h[3e][il][il]o" for "hello".
+	Pattern string
+	// Variants is how many distinct prefixes Pattern covers.
+	Variants int
+
+	sets []string // the characters allowed at each position, as typed first
+}
+
+// Expand returns the lookalike prefixes of word, which may use any letters
+// and digits in either case.
+func Expand(word string) (Expansion, error) {
+	if word == "" {
+		return Expansion{}, fmt.Errorf("nothing to expand")
+	}
+	e := Expansion{Variants: 1}
+	var b strings.Builder
+	for i := 0; i < len(word); i++ {
+		c := word[i]
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		set, ok := alternatives[c]
+		if !ok {
+			if !strings.ContainsRune(base32check.Alphabet, rune(c)) {
+				return Expansion{}, fmt.Errorf("character '%c' at position %d has no base32 lookalike (use a-z and 0-9)", word[i], i)
+			}
+			set = string(c)
+		}
+		e.sets = append(e.sets, set)
+		e.Variants *= len(set)
+		if len(set) == 1 {
+			b.WriteString(set)
+		} else {
+			sorted := []byte(set)
+			slices.Sort(sorted)
+			b.WriteString("[" + string(sorted) + "]")
+		}
+	}
+	e.Pattern = b.String()
+	return e, nil
+}
+
+// List returns up to max of the variants, starting with the word as typed
+// (or its closest base32 spelling) and varying the last positions first.
+func (e Expansion) List(max int) []string {
+	var out []string
+	idx := make([]int, len(e.sets))
+	buf := make([]byte, len(e.sets))
+	for len(out) < max {
+		for i, set := range e.sets {
+			buf[i] = set[idx[i]]
+		}
+		out = append(out, string(buf))
+		// Advance like an odometer, last position fastest.
+		i := len(idx) - 1
+		for ; i >= 0; i-- {
+			if idx[i]++; idx[i] < len(e.sets[i]) {
+				break
+			}
+			idx[i] = 0
+		}
+		if i < 0 {
+			break
+		}
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+package lookalike
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/base32check"
+)
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		word     string
+		pattern  string
+		variants int
+	}{
+		{"hello", "h[3e][il][il]o", 8},
+		{"HE11O", "h[3e][il][il]o", 8},
+		{"h0me", "hom[3e]", 2},
+		{"web", "w[3e][6b]", 4},
+		{"mxw", "mxw", 1},
+		{"8ig", "b[il][6g]", 4},
+	}
+	for _, tt := range tests {
+		e, err := Expand(tt.word)
+		if err != nil {
+			t.Errorf("Expand(%q): %v", tt.word, err)
+			continue
+		}
+		if e.Pattern != tt.pattern {
+			t.Errorf("Expand(%q).Pattern = %q, want %q", tt.word, e.Pattern, tt.pattern)
+		}
+		if e.Variants != tt.variants {
+			t.Errorf("Expand(%q).Variants = %d, want %d", tt.word, e.Variants, tt.variants)
+		}
+	}
+
+	for _, word := range []string{"", "he-llo", "héllo"} {
+		if _, err := Expand(word); err == nil {
+			t.Errorf("Expand(%q) succeeded", word)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	e, err := Expand("hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := e.List(100)
+	if len(all) != e.Variants {
+		t.Fatalf("List returned %d variants, want %d", len(all), e.Variants)
+	}
+	if all[0] != "hello" {
+		t.Errorf("first variant = %q, want the word as typed", all[0])
+	}
+	p := base32check.MustParsePattern(e.Pattern)
+	seen := map[string]bool{}
+	for _, v := range all {
+		if seen[v] {
+			t.Errorf("duplicate variant %q", v)
+		}
+		seen[v] = true
+		for i := 0; i < len(v); i++ {
+			if p[i]&(1<<strings.IndexByte(base32check.Alphabet, v[i])) == 0 {
+				t.Errorf("variant %q is not matched by %q", v, e.Pattern)
+				break
+			}
+		}
+	}
+	if got := e.List(3); !slices.Equal(got, all[:3]) {
+		t.Errorf("List(3) = %v, want %v", got, all[:3])
+	}
+}
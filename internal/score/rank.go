@@ -0,0 +1,105 @@
+package score
+
+import (
+	"context"
+	"slices"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/generator"
+)
+
+// Entry is a search result with its score.
+type Entry struct {
+	Result generator.Result
+	Score  Score
+}
+
+// RateResult scores r after the prefix it matched.
+func RateResult(r generator.Result) Entry {
+	return Entry{Result: r, Score: Rate(r.Address, PrefixLen(r.Target))}
+}
+
+// Rank scores results and returns them best first. Ties keep their
+// original order.
+func Rank(results []generator.Result) []Entry {
+	entries := make([]Entry, len(results))
+	for i, r := range results {
+		entries[i] = RateResult(r)
+	}
+	slices.SortStableFunc(entries, compare)
+	return entries
+}
+
+// compare orders entries by descending total score.
+func compare(a, b Entry) int {
+	switch {
+	case a.Score.Total > b.Score.Total:
+		return -1
+	case a.Score.Total < b.Score.Total:
+		return 1
+	}
+	return 0
+}
+
+// TopK keeps the k best-scoring results seen so far.
+type TopK struct {
+	k       int
+	entries []Entry
+}
+
+// NewTopK returns an empty TopK holding at most k results.
+func NewTopK(k int) *TopK {
+	return &TopK{k: k}
+}
+
+// Add scores r and keeps it if it is among the k best so far, which it
+// reports. Among equal scores the earlier result stays ahead.
+func (t *TopK) Add(r generator.Result) bool {
+	e := RateResult(r)
+	i, _ := slices.BinarySearchFunc(t.entries, e, func(a, b Entry) int {
+		if c := compare(a, b); c != 0 {
+			return c
+		}
+		return -1 // place after equal scores
+	})
+	if i >= t.k {
+		return false
+	}
+	t.entries = slices.Insert(t.entries, i, e)
+	if len(t.entries) > t.k {
+		t.entries = t.entries[:t.k]
+	}
+	return true
+}
+
+// Entries returns the kept results, best first.
+func (t *TopK) Entries() []Entry {
+	return slices.Clone(t.entries)
+}
+
+// Collect runs g, which must be configured as continuous, until it stops or
+// ctx ends, and returns the k best-scoring matches. Bounding ctx with a
+// timeout turns it into a search with a time budget. Each stats tick is
+// passed to stats, if set.
+func Collect(ctx context.Context, g *generator.Generator, k int, stats func(generator.Stats)) []Entry {
+	top := NewTopK(k)
+	resultCh, statsCh := g.Start(ctx)
+	for resultCh != nil || statsCh != nil {
+		select {
+		case r, ok := <-resultCh:
+			if !ok {
+				resultCh = nil
+				continue
+			}
+			top.Add(r)
+		case st, ok := <-statsCh:
+			if !ok {
+				statsCh = nil
+				continue
+			}
+			if stats != nil {
+				stats(st)
+			}
+		}
+	}
+	return top.Entries()
+}
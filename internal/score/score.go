@@ -0,0 +1,167 @@
+// Package score rates how well an address reads after its matched prefix,
+// so the best of several matches can be picked.
+//
+// A score combines three signals over the Window characters after the
+// prefix: how much of the text keeps a pronounceable consonant/vowel rhythm,
+// how much of it is covered by dictionary words (words.txt), and how many
+// lookalike pairs it contains. Base32 already leaves out 0, 1, 8 and 9, but
+// 2/z, 5/s and 6/b/g still blur together, as do rn/m, vv/w and cl/d.
+package score
+
+import (
+	_ "embed"
+	"strings"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/base32check"
+)
+
+// Window is how many characters after the prefix are scored; readers
+// rarely look further into an address.
+const Window = 16
+
+// Weights of the signals in Score.Total.
+const (
+	wordWeight        = 1.0
+	leadWordBonus     = 0.5 // a word right after the prefix reads as one name
+	confusablePenalty = 0.25
+)
+
+//go:embed words.txt
+var wordList string
+
+var words, maxWordLen = loadWords(wordList)
+
+// confusables are adjacent pairs that are easily misread for each other or
+// for a single letter.
+var confusables = []string{"rn", "vv", "cl", "2z", "z2", "5s", "s5", "6b", "b6", "6g", "g6"}
+
+// Score is the readability of one address; higher reads better.
+type Score struct {
+	// Pronounceable is the share of characters that keep a consonant/vowel
+	// rhythm, with at most two consonants or two vowels in a row.
+	Pronounceable float64
+	// Words is the share of characters covered by dictionary words.
+	Words float64
+	// LeadWord is the dictionary word starting right after the prefix, if any.
+	LeadWord string
+	// Confusable counts lookalike pairs.
+	Confusable int
+	Total      float64
+}
+
+// Rate scores addr, with or without its network suffix, skipping the first
+// skip characters (the matched prefix).
+func Rate(addr string, skip int) Score {
+	if i := strings.IndexByte(addr, '.'); i >= 0 {
+		addr = addr[:i]
+	}
+	addr = strings.ToLower(addr)
+	if skip > len(addr) {
+		skip = len(addr)
+	}
+	w := addr[skip:min(len(addr), skip+Window)]
+	if w == "" {
+		return Score{}
+	}
+
+	var s Score
+	s.Pronounceable = float64(pronounceable(w)) / float64(len(w))
+	covered, lead := wordCoverage(w)
+	s.Words = float64(covered) / float64(len(w))
+	s.LeadWord = lead
+	for _, pair := range confusables {
+		s.Confusable += strings.Count(w, pair)
+	}
+
+	s.Total = s.Pronounceable + wordWeight*s.Words - confusablePenalty*float64(s.Confusable)
+	if lead != "" {
+		s.Total += leadWordBonus
+	}
+	return s
+}
+
+// PrefixLen returns how many characters a search target covers, counting a
+// class such as [a-e] as one. An empty or invalid target covers none.
+func PrefixLen(target string) int {
+	p, err := base32check.ParsePattern(target)
+	if err != nil {
+		return 0
+	}
+	return len(p)
+}
+
+// pronounceable counts the letters of w that do not extend a run of more
+// than two consonants or two vowels. Digits never count.
+func pronounceable(w string) int {
+	good, run := 0, 0
+	prev := byte(0)
+	for i := 0; i < len(w); i++ {
+		class := classOf(w[i])
+		if class == prev {
+			run++
+		} else {
+			run = 1
+		}
+		prev = class
+		if class != 'd' && run <= 2 {
+			good++
+		}
+	}
+	return good
+}
+
+// classOf returns 'v' for vowels, 'c' for consonants and 'd' for digits.
+// y counts as a vowel, as it usually reads as one between consonants.
+func classOf(c byte) byte {
+	switch {
+	case strings.IndexByte("aeiouy", c) >= 0:
+		return 'v'
+	case c >= 'a' && c <= 'z':
+		return 'c'
+	}
+	return 'd'
+}
+
+// wordCoverage greedily takes the longest dictionary word at each position
+// and returns how many characters of w the words cover, along with the word
+// at position 0, if any.
+func wordCoverage(w string) (covered int, lead string) {
+	for i := 0; i < len(w); {
+		n := longestWord(w[i:])
+		if n == 0 {
+			i++
+			continue
+		}
+		if i == 0 {
+			lead = w[:n]
+		}
+		covered += n
+		i += n
+	}
+	return covered, lead
+}
+
+// longestWord returns the length of the longest dictionary word s starts
+// with, or 0.
+func longestWord(s string) int {
+	for n := min(len(s), maxWordLen); n >= 3; n-- {
+		if words[s[:n]] {
+			return n
+		}
+	}
+	return 0
+}
+
+func loadWords(list string) (map[string]bool, int) {
+	set := make(map[string]bool)
+	longest := 0
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		set[line] = true
+		longest = max(longest, len(line))
+	}
+	return set, longest
+}
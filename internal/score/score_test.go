@@ -0,0 +1,106 @@
+package score
+
+import (
+	"testing"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/generator"
+)
+
+func TestRate(t *testing.T) {
+	const filler = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaa.b32.i2p"
+	named := Rate("shop"+"cafebluemoon"+filler, 4)
+	if named.LeadWord != "cafe" {
+		t.Errorf("lead word = %q, want \"cafe\"", named.LeadWord)
+	}
+	if named.Words < 0.7 {
+		t.Errorf("words share = %v, want at least 0.7", named.Words)
+	}
+
+	gibberish := Rate("shop"+"qxzkrtvwpmn2ghjk"+filler, 4)
+	if gibberish.Total >= named.Total {
+		t.Errorf("gibberish scored %v, not below named at %v", gibberish.Total, named.Total)
+	}
+	if gibberish.Pronounceable > 0.5 {
+		t.Errorf("gibberish pronounceable = %v, want at most 0.5", gibberish.Pronounceable)
+	}
+
+	lookalikes := Rate("shop"+"ba5s6bka2zaqecae"+filler, 4)
+	if lookalikes.Confusable != 3 {
+		t.Errorf("confusable = %d, want 3", lookalikes.Confusable)
+	}
+	plain := Rate("shop"+"bakakaqebaqecae"+filler, 4)
+	if lookalikes.Total >= plain.Total {
+		t.Errorf("lookalikes scored %v, not below %v", lookalikes.Total, plain.Total)
+	}
+
+	// Only the window after the prefix counts.
+	if got := Rate("cafe"+"qxzkrtvwpmn2ghjk", 4); got.Words != 0 {
+		t.Errorf("prefix was scored: %+v", got)
+	}
+	if got := Rate("abc", 10); got != (Score{}) {
+		t.Errorf("Rate past the end = %+v, want zero", got)
+	}
+}
+
+func TestPrefixLen(t *testing.T) {
+	tests := map[string]int{"": 0, "shop": 4, "??[a-e]x": 4, "bad1": 0}
+	for target, want := range tests {
+		if got := PrefixLen(target); got != want {
+			t.Errorf("PrefixLen(%q) = %d, want %d", target, got, want)
+		}
+	}
+}
+
+func TestRankAndTopK(t *testing.T) {
+	var results []generator.Result
+	for i, rest := range []string{"qxzkrtvwpmn2ghjk", "cafebluemoon", "bakeqxzk", "tigerpond"} {
+		results = append(results, generator.Result{
+			Address: "web" + rest + ".b32.i2p",
+			Target:  "web",
+			// Attempts tells the results apart.
+			Attempts: uint64(i),
+		})
+	}
+
+	ranked := Rank(results)
+	if got := ranked[0].Result.Attempts; got != 1 {
+		t.Errorf("best result is %d, want 1", got)
+	}
+	if got := ranked[len(ranked)-1].Result.Attempts; got != 0 {
+		t.Errorf("worst result is %d, want 0", got)
+	}
+
+	top := NewTopK(2)
+	for _, r := range results {
+		top.Add(r)
+	}
+	entries := top.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("kept %d results, want 2", len(entries))
+	}
+	for i, e := range entries {
+		if e.Result.Attempts != ranked[i].Result.Attempts {
+			t.Errorf("top %d is %d, want %d", i, e.Result.Attempts, ranked[i].Result.Attempts)
+		}
+	}
+	if top.Add(results[0]) {
+		t.Error("the worst result entered a full top 2")
+	}
+}
+
+func TestWordList(t *testing.T) {
+	for w := range words {
+		for i := 0; i < len(w); i++ {
+			if w[i] < 'a' || w[i] > 'z' {
+				t.Errorf("word %q has character %q outside a-z", w, w[i])
+				break
+			}
+		}
+		if len(w) < 3 {
+			t.Errorf("word %q is shorter than 3 characters", w)
+		}
+	}
+	if len(words) < 100 {
+		t.Errorf("only %d words loaded", len(words))
+	}
+}
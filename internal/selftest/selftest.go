@@ -0,0 +1,407 @@
+// Package selftest checks the address, key file and GPU code paths against
+// fixed known-answer vectors, so a broken build is caught before it writes
+// a key file with the wrong address.
+package selftest
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"filippo.io/edwards25519"
+	"github.com/go-i2p/i2p-vanitygen/internal/address"
+	"github.com/go-i2p/i2p-vanitygen/internal/base32check"
+	"github.com/go-i2p/i2p-vanitygen/internal/destination"
+	"github.com/go-i2p/i2p-vanitygen/internal/gpu"
+	"github.com/go-i2p/i2p-vanitygen/internal/keyfile"
+)
+
+// Status is the outcome of one check.
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip" // e.g. no GPU present
+)
+
+// Result is the outcome of one component check.
+type Result struct {
+	Component string        `json:"component"`
+	Status    Status        `json:"status"`
+	Detail    string        `json:"detail,omitempty"`
+	Duration  time.Duration `json:"duration_ns"`
+}
+
+// Options selects the optional checks.
+type Options struct {
+	GPU       bool // compare the GPU kernels against the CPU when a GPU is present
+	GPUDevice int
+}
+
+// skipError marks a check that could not run in this environment.
+type skipError string
+
+func (e skipError) Error() string { return string(e) }
+
+type check struct {
+	component string
+	run       func(opts Options) error
+}
+
+var checks = []check{
+	{"destination.B32Address", checkB32Address},
+	{"destination.MutatePadding", checkMutation},
+	{"base32check prefix matching", checkHasPrefix},
+	{"torv3 checksum", checkTorV3Checksum},
+	{"TorV3Candidate.AdvanceBy", checkAdvanceBy},
+	{"i2p key file layout", checkI2PKeyFile},
+	{"hs_ed25519 file layout", checkTorV3KeyFiles},
+	{"gpu.Worker (i2p)", checkGPUI2P},
+	{"gpu.TorV3Worker", checkGPUTorV3},
+}
+
+// Run executes every check and returns one Result per component, in order.
+func Run(opts Options) []Result {
+	results := make([]Result, 0, len(checks))
+	for _, c := range checks {
+		start := time.Now()
+		err := runCheck(c, opts)
+		r := Result{Component: c.component, Status: StatusPass, Duration: time.Since(start)}
+		var skip skipError
+		switch {
+		case errors.As(err, &skip):
+			r.Status, r.Detail = StatusSkip, skip.Error()
+		case err != nil:
+			r.Status, r.Detail = StatusFail, err.Error()
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// runCheck turns a panic in a check into a failure instead of a crash.
+func runCheck(c check, opts Options) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return c.run(opts)
+}
+
+// Passed reports whether no check failed. Skipped checks do not count.
+func Passed(results []Result) bool {
+	for _, r := range results {
+		if r.Status == StatusFail {
+			return false
+		}
+	}
+	return true
+}
+
+// Known-answer vectors. The Ed25519 key is test 1 of RFC 8032; the addresses
+// were computed independently from the same inputs.
+var (
+	vectorSeed = mustHex("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	vectorPub  = mustHex("d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a")
+)
+
+const (
+	// vectorB32 is the address of vectorDestination: encryption key bytes
+	// 0x00..0xff, zero padding, vectorPub and an Ed25519/ElGamal key cert.
+	vectorB32 = "3i44av76gxyu4b7slbsh35ob5xh3kx6spim3s7ohprwu3bpqaa4q"
+	// vectorB32Counter5 is the same destination after MutatePadding(5),
+	// which puts the counter at offset 256.
+	vectorB32Counter5 = "jph55nqumgx3smdhepxy6ky5kxqwpqblxitc6vbt4pkf5kmlpjla"
+	// vectorB32X25519Counter5 is vectorDestination with crypto type 4 after
+	// MutatePadding(5), which puts the counter at offset 32.
+	vectorB32X25519Counter5 = "eshcpbi2sj6jk572qztzvvpse4cffzb2vi6ofjdardywjb73qpja"
+	vectorOnion             = "25njqamcweflpvkl73j4szahhihoc4xt3ktcgjnpaingr5yhkenl5sid"
+)
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// vectorDestination builds the fixed destination the I2P vectors refer to.
+func vectorDestination() *destination.Destination {
+	d := &destination.Destination{SigningPrivateKey: ed25519.NewKeyFromSeed(vectorSeed)}
+	for i := 0; i < destination.EncryptionKeySize; i++ {
+		d.Raw[i] = byte(i)
+	}
+	copy(d.Raw[destination.EncryptionKeySize+destination.SigningKeyPadding:], vectorPub)
+	cert := d.Raw[destination.EncryptionKeySize+destination.SigningKeySize:]
+	cert[0] = destination.CertTypeKeyCert
+	cert[2] = destination.CertPayloadLength
+	cert[4] = destination.SigTypeEdDSASHA512Ed25519
+	cert[6] = destination.CryptoTypeElGamal
+	return d
+}
+
+// vectorTorV3Candidate builds a Tor candidate from the RFC 8032 seed.
+func vectorTorV3Candidate() (*address.TorV3Candidate, error) {
+	h := sha512.Sum512(vectorSeed)
+	h[0] &= 248
+	h[31] &= 127
+	h[31] |= 64
+	return address.TorV3CandidateFromExpanded(h[:])
+}
+
+func checkB32Address(Options) error {
+	if got := vectorDestination().B32Address(); got != vectorB32 {
+		return fmt.Errorf("got %s, want %s", got, vectorB32)
+	}
+	return nil
+}
+
+func checkMutation(Options) error {
+	for _, tt := range []struct {
+		cryptoType uint16
+		want       string
+	}{
+		{destination.CryptoTypeElGamal, vectorB32Counter5},
+		{destination.CryptoTypeX25519, vectorB32X25519Counter5},
+	} {
+		d := vectorDestination()
+		d.Raw[destination.DestinationSize-1] = byte(tt.cryptoType)
+		d.MutatePadding(5)
+		if got := d.B32Address(); got != tt.want {
+			return fmt.Errorf("crypto type %d, counter 5: got %s, want %s", tt.cryptoType, got, tt.want)
+		}
+		if !d.HasB32Prefix(tt.want) {
+			return errors.New("HasB32Prefix rejects the destination's own address")
+		}
+	}
+	return nil
+}
+
+func checkHasPrefix(Options) error {
+	data := []byte("foobar") // RFC 4648: "mzxw6ytboi"
+	tests := []struct {
+		prefix string
+		want   bool
+	}{
+		{"", true},
+		{"m", true},
+		{"mzxw6", true},
+		{"MZXW6Y", true},
+		{"mzxw6ytboi", true},
+		{"mzxw6ytboj", false}, // last character spans the final partial byte
+		{"n", false},
+		{"mzxw7", false},
+		{"mzxw6ytboia", false}, // longer than the encoding
+	}
+	for _, tt := range tests {
+		if got := base32check.HasPrefixLowerNoPad(data, tt.prefix); got != tt.want {
+			return fmt.Errorf("prefix %q: got %v, want %v", tt.prefix, got, tt.want)
+		}
+		if got := base32check.MustPrefixMask(tt.prefix).Match(data); got != tt.want {
+			return fmt.Errorf("prefix mask %q: got %v, want %v", tt.prefix, got, tt.want)
+		}
+	}
+	return nil
+}
+
+func checkTorV3Checksum(Options) error {
+	pub := ed25519.NewKeyFromSeed(vectorSeed).Public().(ed25519.PublicKey)
+	if !bytes.Equal(pub, vectorPub) {
+		return fmt.Errorf("ed25519 public key mismatch: %x", pub)
+	}
+	if got := address.OnionAddress(vectorPub); got != vectorOnion {
+		return fmt.Errorf("got %s, want %s", got, vectorOnion)
+	}
+	return nil
+}
+
+func checkAdvanceBy(Options) error {
+	const steps = 1000
+
+	stepped, err := vectorTorV3Candidate()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(stepped.PublicKeyBytes(), vectorPub) {
+		return fmt.Errorf("candidate from expanded key has public key %x, want %x", stepped.PublicKeyBytes(), vectorPub)
+	}
+	jumped := stepped.Clone()
+	for i := 0; i < steps; i++ {
+		stepped.Advance()
+	}
+	jumped.AdvanceBy(steps)
+
+	if !bytes.Equal(stepped.PublicKeyBytes(), jumped.PublicKeyBytes()) {
+		return fmt.Errorf("Advance x%d and AdvanceBy(%d) disagree", steps, steps)
+	}
+	// The advanced scalar must still match the advanced point.
+	priv := jumped.ExpandedPrivateKey()
+	scalar, err := edwards25519.NewScalar().SetCanonicalBytes(priv[:32])
+	if err != nil {
+		return fmt.Errorf("advanced scalar: %w", err)
+	}
+	if !bytes.Equal(new(edwards25519.Point).ScalarBaseMult(scalar).Bytes(), priv[32:]) {
+		return errors.New("advanced scalar does not derive the advanced public key")
+	}
+	return nil
+}
+
+func checkI2PKeyFile(Options) error {
+	dir, err := os.MkdirTemp("", "vanitygen-selftest-")
+	if err != nil {
+		return skipError("no temporary directory: " + err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "keys.dat")
+	if err := vectorDestination().SaveKeys(path); err != nil {
+		return err
+	}
+	if fi, err := os.Stat(path); err != nil {
+		return err
+	} else if fi.Size() != destination.KeysFileSize {
+		return fmt.Errorf("key file is %d bytes, want %d", fi.Size(), destination.KeysFileSize)
+	}
+	info, err := keyfile.InspectI2P(path)
+	if err != nil {
+		return err
+	}
+	if !info.OK() {
+		return fmt.Errorf("%v", info.Problems)
+	}
+	if info.Address != vectorB32+".b32.i2p" {
+		return fmt.Errorf("reloaded address %s, want %s.b32.i2p", info.Address, vectorB32)
+	}
+	return nil
+}
+
+func checkTorV3KeyFiles(Options) error {
+	dir, err := os.MkdirTemp("", "vanitygen-selftest-")
+	if err != nil {
+		return skipError("no temporary directory: " + err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	cand, err := vectorTorV3Candidate()
+	if err != nil {
+		return err
+	}
+	if err := cand.SaveKeys(dir); err != nil {
+		return err
+	}
+
+	want := map[string][]byte{
+		keyfile.TorPublicKeyFile: append([]byte(address.TorV3PublicKeyHeader), vectorPub...),
+		keyfile.TorHostnameFile:  []byte(vectorOnion + ".onion\n"),
+	}
+	for name, content := range want {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(got, content) {
+			return fmt.Errorf("%s: got %q, want %q", name, got, content)
+		}
+	}
+	secret, err := os.ReadFile(filepath.Join(dir, keyfile.TorSecretKeyFile))
+	if err != nil {
+		return err
+	}
+	if len(secret) != 96 || string(secret[:32]) != address.TorV3SecretKeyHeader {
+		return fmt.Errorf("%s: bad header or size %d", keyfile.TorSecretKeyFile, len(secret))
+	}
+
+	info, err := keyfile.InspectTorV3(dir)
+	if err != nil {
+		return err
+	}
+	if !info.OK() {
+		return fmt.Errorf("%v", info.Problems)
+	}
+	return nil
+}
+
+func checkGPUI2P(opts Options) error {
+	if !opts.GPU || !gpu.Available() {
+		return skipError("no GPU")
+	}
+	// Search for the full address of a counter in the middle of the batch;
+	// the kernel must find exactly that counter.
+	const target = 1234
+	d := vectorDestination()
+	template := d.Raw
+	d.MutatePadding(target)
+	want := d.B32Address()
+
+	w, err := gpu.NewWorker(gpu.WorkerConfig{
+		DeviceIndex:   opts.GPUDevice,
+		DestTemplate:  template,
+		CounterOffset: d.CounterOffset(),
+		Prefix:        want,
+		BatchSize:     4096,
+	})
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	res, err := w.RunBatch(0)
+	if err != nil {
+		return err
+	}
+	if !res.Found || res.MatchCounter != target {
+		return fmt.Errorf("GPU returned %+v, CPU expects counter %d (%s)", res, target, want)
+	}
+	return nil
+}
+
+func checkGPUTorV3(opts Options) error {
+	if !opts.GPU || !gpu.Available() {
+		return skipError("no GPU")
+	}
+	const (
+		keyCount = 2048
+		target   = 1234
+	)
+	cand, err := vectorTorV3Candidate()
+	if err != nil {
+		return err
+	}
+	pubkeys := make([]byte, 0, keyCount*32)
+	var want string
+	for i := 0; i < keyCount; i++ {
+		if i == target {
+			want = cand.Address()
+		}
+		pubkeys = append(pubkeys, cand.PublicKeyBytes()...)
+		cand.Advance()
+	}
+
+	w, err := gpu.NewTorV3Worker(gpu.TorV3WorkerConfig{
+		DeviceIndex: opts.GPUDevice,
+		Prefix:      want,
+		BatchSize:   keyCount,
+	})
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	res, err := w.RunBatch(pubkeys, keyCount)
+	if err != nil {
+		return err
+	}
+	if !res.Found || res.MatchCounter != target {
+		return fmt.Errorf("GPU returned %+v, CPU expects key %d (%s)", res, target, want)
+	}
+	return nil
+}
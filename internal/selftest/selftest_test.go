@@ -0,0 +1,70 @@
+package selftest
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func TestRunPasses(t *testing.T) {
+	results := Run(Options{})
+	if len(results) != len(checks) {
+		t.Fatalf("got %d results for %d checks", len(results), len(checks))
+	}
+	for _, r := range results {
+		if r.Status == StatusFail {
+			t.Errorf("%s: %s", r.Component, r.Detail)
+		}
+	}
+	if !Passed(results) {
+		t.Error("Passed = false")
+	}
+}
+
+func TestFailureIsReported(t *testing.T) {
+	saved := checks
+	defer func() { checks = saved }()
+	checks = []check{
+		{"panics", func(Options) error { panic("boom") }},
+		{"skips", func(Options) error { return skipError("not here") }},
+	}
+
+	results := Run(Options{})
+	if results[0].Status != StatusFail || results[0].Detail != "panic: boom" {
+		t.Errorf("panicking check = %+v", results[0])
+	}
+	if results[1].Status != StatusSkip {
+		t.Errorf("skipped check = %+v", results[1])
+	}
+	if Passed(results) {
+		t.Error("Passed = true with a failing check")
+	}
+}
+
+// TestMutationVectors derives the mutated-address vectors from hand-built
+// destination bytes, independently of destination.MutatePadding.
+func TestMutationVectors(t *testing.T) {
+	for _, tt := range []struct {
+		cryptoType byte
+		offset     int
+		want       string
+	}{
+		{0, 256, vectorB32Counter5},
+		{4, 32, vectorB32X25519Counter5},
+	} {
+		var raw [391]byte
+		for i := 0; i < 256; i++ {
+			raw[i] = byte(i)
+		}
+		copy(raw[256+96:], vectorPub)
+		copy(raw[384:], []byte{5, 0, 4, 0, 7, 0, tt.cryptoType})
+		binary.LittleEndian.PutUint64(raw[tt.offset:], 5)
+		sum := sha256.Sum256(raw[:])
+		got := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(sum[:]))
+		if got != tt.want {
+			t.Errorf("crypto type %d: got %s, want %s", tt.cryptoType, got, tt.want)
+		}
+	}
+}
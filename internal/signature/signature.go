@@ -0,0 +1,188 @@
+// Package signature makes and checks detached Ed25519 signatures with the
+// identities this tool generates, so the owner of an address can sign a
+// statement about it.
+//
+// The signed bytes are not the message alone but
+//
+//	"i2p-vanitygen signed message v1\n" + address + "\n" + message
+//
+// where address is the full .b32.i2p or .onion address, so a signature is
+// only valid for the address it names. Signatures are stored as JSON:
+//
+//	{"version": 1, "address": "...onion", "signature": "<base64>"}
+//
+// I2P signatures also carry the base64 destination, because a b32 address is
+// a hash and does not contain the public key.
+package signature
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/address"
+	"github.com/go-i2p/i2p-vanitygen/internal/destination"
+	"github.com/go-i2p/i2p-vanitygen/internal/keyfile"
+)
+
+// Version is the signature document version.
+const Version = 1
+
+const payloadPrefix = "i2p-vanitygen signed message v1\n"
+
+// Signature is a detached signature over a message.
+type Signature struct {
+	Version     int    `json:"version"`
+	Address     string `json:"address"`
+	Destination string `json:"destination,omitempty"` // I2P base64, required for .b32.i2p
+	Signature   []byte `json:"signature"`
+}
+
+// Signer signs messages for one address.
+type Signer struct {
+	address     string
+	destination string
+	sign        func(payload []byte) []byte
+}
+
+// Address returns the full address signatures are tied to.
+func (s *Signer) Address() string { return s.address }
+
+// Sign returns a detached signature of message.
+func (s *Signer) Sign(message []byte) *Signature {
+	return &Signature{
+		Version:     Version,
+		Address:     s.address,
+		Destination: s.destination,
+		Signature:   s.sign(payload(s.address, message)),
+	}
+}
+
+// Load reads signing keys from an I2P .dat key file, a Tor hidden service
+// directory, or a Tor hs_ed25519_secret_key file.
+func Load(path string) (*Signer, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return loadTorV3(filepath.Join(path, keyfile.TorSecretKeyFile))
+	}
+	if filepath.Base(path) == keyfile.TorSecretKeyFile {
+		return loadTorV3(path)
+	}
+	return loadI2P(path)
+}
+
+func loadI2P(path string) (*Signer, error) {
+	d, err := destination.LoadKeys(path)
+	if err != nil {
+		return nil, err
+	}
+	// Refuse to sign for a destination whose public key is not ours.
+	if !bytes.Equal(d.SigningPrivateKey.Public().(ed25519.PublicKey), d.SigningPublicKey()) {
+		return nil, fmt.Errorf("%s: signing seed does not match the destination", path)
+	}
+	priv := d.SigningPrivateKey
+	return &Signer{
+		address:     d.FullB32Address(),
+		destination: d.Base64(),
+		sign:        func(p []byte) []byte { return ed25519.Sign(priv, p) },
+	}, nil
+}
+
+func loadTorV3(path string) (*Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	hdr := address.TorV3SecretKeyHeader
+	if len(data) != len(hdr)+64 || !strings.HasPrefix(string(data), hdr) {
+		return nil, fmt.Errorf("%s is not a Tor v3 secret key file", path)
+	}
+	cand, err := address.TorV3CandidateFromExpanded(data[len(hdr):])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &Signer{address: cand.FullAddress(), sign: cand.Sign}, nil
+}
+
+// Verify checks sig against message and returns nil if it is a valid
+// signature by sig.Address.
+func Verify(sig *Signature, message []byte) error {
+	if sig.Version != Version {
+		return fmt.Errorf("unsupported signature version %d", sig.Version)
+	}
+	if len(sig.Signature) != ed25519.SignatureSize {
+		return fmt.Errorf("signature must be %d bytes, got %d", ed25519.SignatureSize, len(sig.Signature))
+	}
+
+	var pub ed25519.PublicKey
+	addr := strings.ToLower(sig.Address)
+	switch {
+	case strings.HasSuffix(addr, ".onion"):
+		p, err := address.ParseOnionAddress(addr)
+		if err != nil {
+			return err
+		}
+		pub = p
+	case strings.HasSuffix(addr, ".b32.i2p"):
+		if sig.Destination == "" {
+			return errors.New("I2P signature has no destination")
+		}
+		d, err := destination.ParseBase64(sig.Destination)
+		if err != nil {
+			return err
+		}
+		if d.FullB32Address() != addr {
+			return fmt.Errorf("destination hashes to %s, not %s", d.FullB32Address(), addr)
+		}
+		cert := d.Raw[destination.EncryptionKeySize+destination.SigningKeySize:]
+		if cert[0] != destination.CertTypeKeyCert || cert[3] != 0 || cert[4] != destination.SigTypeEdDSASHA512Ed25519 {
+			return errors.New("destination does not use an Ed25519 signing key")
+		}
+		pub = d.SigningPublicKey()
+	default:
+		return fmt.Errorf("unrecognised address %q", sig.Address)
+	}
+
+	if !ed25519.Verify(pub, payload(addr, message), sig.Signature) {
+		return errors.New("signature does not match")
+	}
+	return nil
+}
+
+func payload(addr string, message []byte) []byte {
+	p := make([]byte, 0, len(payloadPrefix)+len(addr)+1+len(message))
+	p = append(p, payloadPrefix...)
+	p = append(p, addr...)
+	p = append(p, '\n')
+	return append(p, message...)
+}
+
+// Write encodes sig as indented JSON.
+func Write(w io.Writer, sig *Signature) error {
+	data, err := json.MarshalIndent(sig, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// Read decodes a signature written by Write.
+func Read(r io.Reader) (*Signature, error) {
+	var sig Signature
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&sig); err != nil {
+		return nil, fmt.Errorf("reading signature: %w", err)
+	}
+	return &sig, nil
+}
@@ -0,0 +1,77 @@
+package signature
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-i2p/i2p-vanitygen/internal/address"
+	"github.com/go-i2p/i2p-vanitygen/internal/keyfile"
+)
+
+func TestSignVerify(t *testing.T) {
+	dir := t.TempDir()
+
+	i2p, err := address.I2PScheme{}.NewCandidate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	i2pPath := filepath.Join(dir, "keys.dat")
+	if err := i2p.SaveKeys(i2pPath); err != nil {
+		t.Fatal(err)
+	}
+
+	tor, err := address.NewTorV3Candidate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tor.AdvanceBy(99) // seedless, like a found vanity key
+	torDir := filepath.Join(dir, "hs")
+	if err := tor.SaveKeys(torDir); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{i2pPath, i2p.FullAddress()},
+		{torDir, tor.FullAddress()},
+		{filepath.Join(torDir, keyfile.TorSecretKeyFile), tor.FullAddress()},
+	}
+	msg := []byte("example.com is operated by the owner of this address\n")
+	for _, tt := range tests {
+		s, err := Load(tt.path)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.path, err)
+		}
+		if s.Address() != tt.want {
+			t.Fatalf("%s: address %s, want %s", tt.path, s.Address(), tt.want)
+		}
+
+		// Round-trip through the file format.
+		var buf bytes.Buffer
+		if err := Write(&buf, s.Sign(msg)); err != nil {
+			t.Fatal(err)
+		}
+		sig, err := Read(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Verify(sig, msg); err != nil {
+			t.Errorf("%s: Verify: %v", tt.path, err)
+		}
+
+		if err := Verify(sig, append(msg, '!')); err == nil {
+			t.Errorf("%s: tampered message verified", tt.path)
+		}
+		other := *sig
+		other.Address = i2p.FullAddress()
+		if other.Address == sig.Address {
+			other.Address = tor.FullAddress()
+		}
+		if err := Verify(&other, msg); err == nil {
+			t.Errorf("%s: signature verified for %s", tt.path, other.Address)
+		}
+	}
+}
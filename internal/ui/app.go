@@ -24,9 +24,13 @@ import (
 	"gioui.org/widget/material"
 
 	"github.com/go-i2p/i2p-vanitygen/internal/address"
+	"github.com/go-i2p/i2p-vanitygen/internal/base32check"
+	"github.com/go-i2p/i2p-vanitygen/internal/blocklist"
 	"github.com/go-i2p/i2p-vanitygen/internal/config"
+	"github.com/go-i2p/i2p-vanitygen/internal/format"
 	"github.com/go-i2p/i2p-vanitygen/internal/generator"
 	"github.com/go-i2p/i2p-vanitygen/internal/gpu"
+	"github.com/go-i2p/i2p-vanitygen/internal/selftest"
 	"github.com/go-i2p/i2p-vanitygen/internal/updater"
 	"github.com/go-i2p/i2p-vanitygen/internal/version"
 )
@@ -35,6 +39,7 @@ type state struct {
 	mu         sync.Mutex
 	running    bool
 	prefix     string
+	suffix     string
 	cores      int
 	status     string
 	speed      string
@@ -42,6 +47,8 @@ type state struct {
 	estimate   string
 	result     string
 	lastResult *generator.Result
+	best       *generator.Result // closest candidate of the running search
+	bestText   string
 	cancel     context.CancelFunc
 	gen        *generator.Generator
 
@@ -55,6 +62,10 @@ type state struct {
 	useGPU       bool
 	gpuDevice    int
 
+	// Self-test
+	selftestRunning bool
+	selftestResults []selftest.Result
+
 	// Auto-update
 	updateAvailable   bool
 	updateRelease     *updater.Release
@@ -75,6 +86,7 @@ func Run(w *app.Window) error {
 
 	var (
 		prefixEditor     widget.Editor
+		suffixEditor     widget.Editor
 		startBtn         widget.Clickable
 		saveBtn          widget.Clickable
 		coreSlider       widget.Float
@@ -85,10 +97,12 @@ func Run(w *app.Window) error {
 		updateDismissBtn widget.Clickable
 		updateInstallBtn widget.Clickable
 		updateCancelBtn  widget.Clickable
+		selftestBtn      widget.Clickable
 		scrollList       widget.List
 	)
 	scrollList.Axis = layout.Vertical
 	prefixEditor.SingleLine = true
+	suffixEditor.SingleLine = true
 	coreSlider.Value = 1.0 // Start at max cores
 
 	initNetwork := address.ParseNetwork(cfg.Network)
@@ -262,6 +276,9 @@ func Run(w *app.Window) error {
 			if saveBtn.Clicked(gtx) {
 				s.save()
 			}
+			if selftestBtn.Clicked(gtx) && !s.running {
+				s.runSelftest(w)
+			}
 
 			// Handle network selector
 			if !s.running {
@@ -270,6 +287,7 @@ func Run(w *app.Window) error {
 					s.scheme = address.I2PScheme{}
 					s.result = ""
 					s.lastResult = nil
+					s.best, s.bestText = nil, ""
 					s.updateEstimate()
 				}
 				if netTorBtn.Clicked(gtx) && s.network != address.NetworkTorV3 {
@@ -277,6 +295,7 @@ func Run(w *app.Window) error {
 					s.scheme = address.TorV3Scheme{}
 					s.result = ""
 					s.lastResult = nil
+					s.best, s.bestText = nil, ""
 					s.updateEstimate()
 				}
 			}
@@ -303,12 +322,14 @@ func Run(w *app.Window) error {
 			}
 
 			newPrefix := strings.ToLower(prefixEditor.Text())
-			if newPrefix != s.prefix {
+			newSuffix := strings.ToLower(suffixEditor.Text())
+			if newPrefix != s.prefix || newSuffix != s.suffix {
 				s.prefix = newPrefix
+				s.suffix = newSuffix
 				s.updateEstimate()
 			}
 
-			layoutApp(gtx, th, s, &prefixEditor, &startBtn, &saveBtn, &coreSlider, maxCores, &gpuToggle, &netI2PBtn, &netTorBtn, &updateBannerBtn, &updateDismissBtn, &scrollList)
+			layoutApp(gtx, th, s, &prefixEditor, &suffixEditor, &startBtn, &saveBtn, &coreSlider, maxCores, &gpuToggle, &netI2PBtn, &netTorBtn, &updateBannerBtn, &updateDismissBtn, &selftestBtn, &scrollList)
 
 			// Draw update overlay on top
 			s.mu.Lock()
@@ -330,13 +351,13 @@ func Run(w *app.Window) error {
 	}
 }
 
-func layoutApp(gtx layout.Context, th *material.Theme, s *state, prefixEditor *widget.Editor, startBtn, saveBtn *widget.Clickable, coreSlider *widget.Float, maxCores int, gpuToggle *widget.Bool, netI2PBtn, netTorBtn *widget.Clickable, updateBannerBtn, updateDismissBtn *widget.Clickable, scrollList *widget.List) layout.Dimensions {
+func layoutApp(gtx layout.Context, th *material.Theme, s *state, prefixEditor, suffixEditor *widget.Editor, startBtn, saveBtn *widget.Clickable, coreSlider *widget.Float, maxCores int, gpuToggle *widget.Bool, netI2PBtn, netTorBtn *widget.Clickable, updateBannerBtn, updateDismissBtn, selftestBtn *widget.Clickable, scrollList *widget.List) layout.Dimensions {
 	// Fill window width with side padding
 	gtx.Constraints.Min.X = gtx.Constraints.Max.X
 	return layout.Inset{Top: unit.Dp(4), Bottom: unit.Dp(4), Left: unit.Dp(20), Right: unit.Dp(20)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 		return layout.N.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
-			// Sections: 0=header, 1=spacer, 2=update banner, 3=input card, 4=spacer, 5=results card, 6=bottom spacer
-			const numSections = 7
+			// Sections: 0=header, 1=spacer, 2=update banner, 3=input card, 4=spacer, 5=results card, 6=spacer, 7=self-test card, 8=bottom spacer
+			const numSections = 9
 
 			list := material.List(th, scrollList)
 			list.Indicator.MinorWidth = unit.Dp(4)
@@ -359,12 +380,16 @@ func layoutApp(gtx layout.Context, th *material.Theme, s *state, prefixEditor *w
 						return layoutUpdateBanner(gtx, th, rel, updateBannerBtn, updateDismissBtn)
 					})
 				case 3: // Input card
-					return layoutInputCard(gtx, th, s, prefixEditor, startBtn, coreSlider, maxCores, gpuToggle, netI2PBtn, netTorBtn)
+					return layoutInputCard(gtx, th, s, prefixEditor, suffixEditor, startBtn, coreSlider, maxCores, gpuToggle, netI2PBtn, netTorBtn)
 				case 4: // Spacer between cards
 					return layout.Spacer{Height: unit.Dp(14)}.Layout(gtx)
 				case 5: // Results card
 					return layoutResultsCard(gtx, th, s, saveBtn)
-				case 6: // Bottom spacer
+				case 6: // Spacer between cards
+					return layout.Spacer{Height: unit.Dp(14)}.Layout(gtx)
+				case 7: // Self-test card
+					return layoutSelftestCard(gtx, th, s, selftestBtn)
+				case 8: // Bottom spacer
 					return layout.Spacer{Height: unit.Dp(4)}.Layout(gtx)
 				}
 				return layout.Dimensions{}
@@ -636,7 +661,7 @@ func layoutLogoDots(gtx layout.Context, dotSize, gap unit.Dp) layout.Dimensions
 	return layout.Dimensions{Size: image.Pt(totalW, totalH)}
 }
 
-func layoutInputCard(gtx layout.Context, th *material.Theme, s *state, prefixEditor *widget.Editor, startBtn *widget.Clickable, coreSlider *widget.Float, maxCores int, gpuToggle *widget.Bool, netI2PBtn, netTorBtn *widget.Clickable) layout.Dimensions {
+func layoutInputCard(gtx layout.Context, th *material.Theme, s *state, prefixEditor, suffixEditor *widget.Editor, startBtn *widget.Clickable, coreSlider *widget.Float, maxCores int, gpuToggle *widget.Bool, netI2PBtn, netTorBtn *widget.Clickable) layout.Dimensions {
 	return cardWithBorder(gtx, func(gtx layout.Context) layout.Dimensions {
 		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
 			// Network selector
@@ -659,16 +684,29 @@ func layoutInputCard(gtx layout.Context, th *material.Theme, s *state, prefixEdi
 					layout.Rigid(sectionLabel(th, "TARGET PREFIX")),
 					layout.Rigid(vspace(8)),
 					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-						return styledInput(gtx, th, prefixEditor, "e.g. hello", !s.running)
+						return styledInput(gtx, th, prefixEditor, "e.g. hello, or ??cafe with wildcards", !s.running)
+					}),
+				)
+			}),
+			layout.Rigid(vspace(14)),
+
+			// Optional suffix
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				gtx.Constraints.Min.X = gtx.Constraints.Max.X
+				return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+					layout.Rigid(sectionLabel(th, "TARGET SUFFIX (OPTIONAL, CPU ONLY)")),
+					layout.Rigid(vspace(8)),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return styledInput(gtx, th, suffixEditor, "e.g. shop", !s.running)
 					}),
 				)
 			}),
 			// Validation
 			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-				if s.prefix == "" {
+				if s.prefix == "" && s.suffix == "" {
 					return layout.Dimensions{}
 				}
-				if err := s.scheme.ValidatePrefix(s.prefix); err != nil {
+				if err := s.validate(); err != nil {
 					return layout.Inset{Top: unit.Dp(6)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 						lbl := material.Caption(th, err.Error())
 						lbl.Color = color.NRGBA{R: 0xff, G: 0x44, B: 0x44, A: 0xff}
@@ -824,6 +862,7 @@ func layoutResultsCard(gtx layout.Context, th *material.Theme, s *state, saveBtn
 	estimate := s.estimate
 	result := s.result
 	hasResult := s.lastResult != nil
+	bestText := s.bestText
 	s.mu.Unlock()
 
 	return cardWithBorder(gtx, func(gtx layout.Context) layout.Dimensions {
@@ -862,6 +901,16 @@ func layoutResultsCard(gtx layout.Context, th *material.Theme, s *state, saveBtn
 					)
 				})
 			}),
+
+			// Best partial match while searching
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				if bestText == "" || hasResult {
+					return layout.Dimensions{}
+				}
+				return layout.Inset{Top: unit.Dp(10)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return statBox(gtx, th, "BEST SO FAR", bestText)
+				})
+			}),
 			layout.Rigid(vspace(15)),
 
 			// Generated Address
@@ -875,8 +924,12 @@ func layoutResultsCard(gtx layout.Context, th *material.Theme, s *state, saveBtn
 			// Save button — force full width
 			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 				gtx.Constraints.Min.X = gtx.Constraints.Max.X
-				btn := material.Button(th, saveBtn, "Save Keys")
-				if hasResult {
+				label := "Save Keys"
+				if !hasResult && bestText != "" {
+					label = "Save Best So Far"
+				}
+				btn := material.Button(th, saveBtn, label)
+				if hasResult || bestText != "" {
 					btn.Background = colorAccent
 					btn.Color = color.NRGBA{A: 0xff}
 				} else {
@@ -891,6 +944,61 @@ func layoutResultsCard(gtx layout.Context, th *material.Theme, s *state, saveBtn
 	})
 }
 
+func layoutSelftestCard(gtx layout.Context, th *material.Theme, s *state, selftestBtn *widget.Clickable) layout.Dimensions {
+	s.mu.Lock()
+	running := s.selftestRunning
+	searching := s.running
+	results := s.selftestResults
+	s.mu.Unlock()
+
+	children := []layout.FlexChild{
+		layout.Rigid(sectionLabel(th, "SELF-TEST")),
+		layout.Rigid(vspace(8)),
+	}
+	for _, r := range results {
+		children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			txt := fmt.Sprintf("%-4s  %s", strings.ToUpper(string(r.Status)), r.Component)
+			if r.Detail != "" {
+				txt += ": " + r.Detail
+			}
+			lbl := material.Body2(th, txt)
+			lbl.Font.Typeface = typefaceMono
+			switch r.Status {
+			case selftest.StatusPass:
+				lbl.Color = colorLogoGreen
+			case selftest.StatusFail:
+				lbl.Color = colorLogoRed
+			default:
+				lbl.Color = colorLabel
+			}
+			return lbl.Layout(gtx)
+		}))
+	}
+	if len(results) > 0 {
+		children = append(children, layout.Rigid(vspace(12)))
+	}
+	children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+		gtx.Constraints.Min.X = gtx.Constraints.Max.X
+		label := "Run Self-Test"
+		if running {
+			label = "Running..."
+		}
+		btn := material.Button(th, selftestBtn, label)
+		btn.Background = color.NRGBA{A: 0}
+		btn.Color = colorAccent
+		if running || searching {
+			btn.Color = colorMuted
+		}
+		btn.Font.Weight = font.SemiBold
+		btn.Inset = layout.Inset{Top: unit.Dp(10), Bottom: unit.Dp(10)}
+		return btn.Layout(gtx)
+	}))
+
+	return cardWithBorder(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+}
+
 // --- Reusable drawing helpers ---
 
 func cardWithBorder(gtx layout.Context, content func(gtx layout.Context) layout.Dimensions) layout.Dimensions {
@@ -1039,25 +1147,45 @@ func vspace(dp float32) func(gtx layout.Context) layout.Dimensions {
 
 // --- State methods ---
 
+// validate checks the prefix and suffix, which must also leave some matches
+// free of the built-in blocklist's words.
+func (s *state) validate() error {
+	if err := address.ValidateSearch(s.scheme, s.prefix, s.suffix); err != nil {
+		return err
+	}
+	if blocklist.Default().PassProbability(s.scheme, s.prefix, s.suffix) == 0 {
+		return fmt.Errorf("every match would contain a blocked word")
+	}
+	return nil
+}
+
+// estimateAttempts returns the average attempts the search needs, counting
+// the matches the blocklist skips.
+func (s *state) estimateAttempts() float64 {
+	attempts := address.EstimateSearchAttempts(s.scheme, s.prefix, s.suffix)
+	return blocklist.Default().AdjustAttempts(s.scheme, s.prefix, s.suffix, attempts)
+}
+
 func (s *state) updateEstimate() {
-	if s.prefix == "" || s.scheme.ValidatePrefix(s.prefix) != nil {
+	if s.validate() != nil {
 		s.mu.Lock()
 		s.estimate = "Awaiting input..."
 		s.mu.Unlock()
 		return
 	}
-	attempts := s.scheme.EstimateAttempts(len(s.prefix))
+	attempts := s.estimateAttempts()
+	gpuActive := s.useGPU && s.gpuAvailable && s.scheme.SupportsGPU() && s.suffix == "" && base32check.IsLiteral(s.prefix)
 
 	var keysPerSec float64
 	switch s.scheme.Network() {
 	case address.NetworkI2P:
 		keysPerSec = 500_000.0 * float64(s.cores)
-		if s.useGPU && s.gpuAvailable && s.scheme.SupportsGPU() {
+		if gpuActive {
 			keysPerSec += 100_000_000.0
 		}
 	case address.NetworkTorV3:
 		keysPerSec = 300_000.0 * float64(s.cores)
-		if s.useGPU && s.gpuAvailable && s.scheme.SupportsGPU() {
+		if gpuActive {
 			keysPerSec += 300_000.0 // GPU worker is CPU-precompute bound
 		}
 	}
@@ -1067,13 +1195,28 @@ func (s *state) updateEstimate() {
 	if seconds < 1 {
 		s.estimate = "< 1 second"
 	} else {
-		s.estimate = "~" + formatDuration(time.Duration(seconds*float64(time.Second)))
+		s.estimate = "~" + format.Duration(time.Duration(seconds*float64(time.Second)))
 	}
 	s.mu.Unlock()
 }
 
 func (s *state) start(w *app.Window) {
-	if s.prefix == "" || s.scheme.ValidatePrefix(s.prefix) != nil {
+	if s.validate() != nil {
+		return
+	}
+	gen, err := generator.NewWithConfig(generator.Config{
+		Scheme:    s.scheme,
+		Prefix:    s.prefix,
+		Suffix:    s.suffix,
+		Reject:    blocklist.Default(),
+		Cores:     s.cores,
+		GPU:       s.useGPU,
+		GPUDevice: s.gpuDevice,
+	})
+	if err != nil {
+		s.mu.Lock()
+		s.status = "Error: " + err.Error()
+		s.mu.Unlock()
 		return
 	}
 
@@ -1084,9 +1227,9 @@ func (s *state) start(w *app.Window) {
 	s.checked = ""
 	s.result = ""
 	s.lastResult = nil
+	s.best, s.bestText = nil, ""
 	s.mu.Unlock()
 
-	gen := generator.New(s.scheme, s.prefix, s.cores, s.useGPU, s.gpuDevice)
 	ctx, cancel := context.WithCancel(context.Background())
 
 	s.mu.Lock()
@@ -1097,22 +1240,17 @@ func (s *state) start(w *app.Window) {
 	resultCh, statsCh := gen.Start(ctx)
 
 	go func() {
-		attempts := s.scheme.EstimateAttempts(len(s.prefix))
+		attempts := s.estimateAttempts()
 		for stats := range statsCh {
 			s.mu.Lock()
-			s.speed = fmt.Sprintf("%s keys/sec", formatNumber(stats.KeysPerSec))
-			s.checked = fmt.Sprintf("%s", formatUint(stats.Checked))
+			s.speed = fmt.Sprintf("%s keys/sec", format.Number(stats.KeysPerSec))
+			s.checked = fmt.Sprintf("%s", format.Uint(stats.Checked))
 			if stats.KeysPerSec > 0 {
-				remaining := attempts - float64(stats.Checked)
-				if remaining < 0 {
-					remaining = 0
-				}
-				secs := remaining / stats.KeysPerSec
-				if secs < 1 {
-					s.estimate = "< 1 second"
-				} else {
-					s.estimate = "~" + formatDuration(time.Duration(secs*float64(time.Second)))
-				}
+				s.estimate = format.ETA(attempts, stats.Checked, stats.KeysPerSec)
+			}
+			if stats.Best != nil {
+				s.best = stats.Best
+				s.bestText = fmt.Sprintf("%s (%d chars)", stats.Best.Address, stats.BestLen)
 			}
 			s.mu.Unlock()
 			w.Invalidate()
@@ -1124,7 +1262,7 @@ func (s *state) start(w *app.Window) {
 			s.mu.Lock()
 			s.lastResult = &result
 			s.result = result.Address
-			s.status = fmt.Sprintf("Found in %s (%s attempts)", formatDuration(result.Duration), formatUint(result.Attempts))
+			s.status = fmt.Sprintf("Found in %s (%s attempts)", format.Duration(result.Duration), format.Uint(result.Attempts))
 			s.running = false
 			s.mu.Unlock()
 			s.updateEstimate()
@@ -1133,6 +1271,30 @@ func (s *state) start(w *app.Window) {
 	}()
 }
 
+// runSelftest runs the known-answer checks in the background, including the
+// GPU comparison when the GPU is enabled.
+func (s *state) runSelftest(w *app.Window) {
+	s.mu.Lock()
+	if s.selftestRunning {
+		s.mu.Unlock()
+		return
+	}
+	s.selftestRunning = true
+	s.selftestResults = nil
+	opts := selftest.Options{GPU: s.useGPU && s.gpuAvailable, GPUDevice: s.gpuDevice}
+	s.mu.Unlock()
+	w.Invalidate()
+
+	go func() {
+		results := selftest.Run(opts)
+		s.mu.Lock()
+		s.selftestRunning = false
+		s.selftestResults = results
+		s.mu.Unlock()
+		w.Invalidate()
+	}()
+}
+
 func (s *state) stop() {
 	s.mu.Lock()
 	s.running = false
@@ -1147,16 +1309,24 @@ func (s *state) stop() {
 func (s *state) save() {
 	s.mu.Lock()
 	r := s.lastResult
+	best := s.best
 	network := s.network
 	s.mu.Unlock()
-	if r == nil {
-		return
-	}
 
 	// Strip suffix to get a short name for the file/dir
-	addr := r.Candidate.Address()
-	if len(addr) > 16 {
-		addr = addr[:16]
+	var addr string
+	switch {
+	case r != nil:
+		addr = r.Candidate.Address()
+		if len(addr) > 16 {
+			addr = addr[:16]
+		}
+	case best != nil:
+		// Without a match, save the closest candidate under a fixed name
+		// so each save replaces the last one.
+		r, addr = best, "best"
+	default:
+		return
 	}
 
 	// Save next to the executable, not the working directory
@@ -1197,49 +1367,3 @@ func (s *state) save() {
 		s.mu.Unlock()
 	}
 }
-
-func formatNumber(n float64) string {
-	if n >= 1_000_000 {
-		return fmt.Sprintf("%.2fM", n/1_000_000)
-	}
-	if n >= 1_000 {
-		return fmt.Sprintf("%.1fK", n/1_000)
-	}
-	return fmt.Sprintf("%.0f", n)
-}
-
-func formatUint(n uint64) string {
-	if n >= 1_000_000_000 {
-		return fmt.Sprintf("%.2fB", float64(n)/1_000_000_000)
-	}
-	if n >= 1_000_000 {
-		return fmt.Sprintf("%.2fM", float64(n)/1_000_000)
-	}
-	if n >= 1_000 {
-		return fmt.Sprintf("%.1fK", float64(n)/1_000)
-	}
-	return fmt.Sprintf("%d", n)
-}
-
-func formatDuration(d time.Duration) string {
-	if d < time.Second {
-		return "< 1s"
-	}
-	d = d.Round(time.Second)
-	h := int(d.Hours())
-	m := int(d.Minutes()) % 60
-	sec := int(d.Seconds()) % 60
-
-	if h > 24 {
-		days := h / 24
-		h = h % 24
-		return fmt.Sprintf("%dd %dh %dm", days, h, m)
-	}
-	if h > 0 {
-		return fmt.Sprintf("%dh %dm %ds", h, m, sec)
-	}
-	if m > 0 {
-		return fmt.Sprintf("%dm %ds", m, sec)
-	}
-	return fmt.Sprintf("%ds", sec)
-}
@@ -5,6 +5,7 @@ import (
 
 	"gioui.org/app"
 
+	"github.com/go-i2p/i2p-vanitygen/internal/cli"
 	"github.com/go-i2p/i2p-vanitygen/internal/ui"
 	"github.com/go-i2p/i2p-vanitygen/internal/updater"
 )
@@ -12,6 +13,12 @@ import (
 func main() {
 	updater.Cleanup()
 
+	// Any arguments select a headless subcommand; no window is opened.
+	if len(os.Args) > 1 {
+		cli.AttachConsole()
+		os.Exit(cli.Run(os.Args[1:]))
+	}
+
 	go func() {
 		w := new(app.Window)
 		w.Option(app.Title("Vanity Address Generator"))